@@ -3,13 +3,23 @@ package main
 import (
 	"context"
 	"errors"
+	"http-calendar/internal/auth"
+	"http-calendar/internal/blob"
 	"http-calendar/internal/config"
+	"http-calendar/internal/digest"
 	"http-calendar/internal/handler"
 	"http-calendar/internal/logger"
+	"http-calendar/internal/mail"
+	"http-calendar/internal/pubsub"
+	"http-calendar/internal/scheduler"
+	"http-calendar/internal/service"
+	"http-calendar/internal/trash"
+	"http-calendar/internal/webhook"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 )
@@ -28,12 +38,144 @@ func run(cfg *config.Config) {
 	mux.HandleFunc("GET /events_for_day", handler.GetEventsForDayHandler)
 	mux.HandleFunc("GET /events_for_week", handler.GetEventsForWeekHandler)
 	mux.HandleFunc("GET /events_for_month", handler.GetEventsForMonthHandler)
+	mux.HandleFunc("GET /events", handler.GetEventsHandler)
+	mux.HandleFunc("GET /events_stream", handler.StreamHandler)
+	mux.HandleFunc("GET /sync", handler.SyncHandler)
+	mux.HandleFunc("POST /update_user", handler.UpdateUserHandler)
+	mux.HandleFunc("GET /user", handler.GetUserHandler)
+	mux.HandleFunc("GET /digest", handler.GetDigestHandler)
+	mux.HandleFunc("POST /create_webhook", handler.CreateWebhookHandler)
+	mux.HandleFunc("POST /delete_webhook", handler.DeleteWebhookHandler)
+	mux.HandleFunc("GET /webhooks", handler.GetWebhooksHandler)
+	mux.HandleFunc("GET /webhook_deliveries", handler.GetWebhookDeliveriesHandler)
+	mux.HandleFunc("POST /share_calendar", handler.ShareCalendarHandler)
+	mux.HandleFunc("POST /unshare_calendar", handler.UnshareCalendarHandler)
+	mux.HandleFunc("GET /shares", handler.GetSharesHandler)
+	mux.HandleFunc("POST /create_calendar", handler.CreateCalendarHandler)
+	mux.HandleFunc("POST /update_calendar", handler.UpdateCalendarHandler)
+	mux.HandleFunc("POST /delete_calendar", handler.DeleteCalendarHandler)
+	mux.HandleFunc("GET /calendars", handler.GetCalendarsHandler)
+	mux.HandleFunc("POST /send_invitations", handler.SendInvitationsHandler)
+	mux.HandleFunc("POST /rsvp", handler.RSVPHandler)
+	mux.HandleFunc("GET /freebusy", handler.GetFreeBusyHandler)
+	mux.HandleFunc("GET /find_slots", handler.FindSlotsHandler)
+	mux.HandleFunc("POST /update_availability", handler.UpdateAvailabilityHandler)
+	mux.HandleFunc("GET /availability", handler.GetAvailabilityHandler)
+	mux.HandleFunc("POST /add_out_of_office", handler.AddOutOfOfficeHandler)
+	mux.HandleFunc("POST /delete_out_of_office", handler.DeleteOutOfOfficeHandler)
+	mux.HandleFunc("POST /create_resource", handler.CreateResourceHandler)
+	mux.HandleFunc("POST /delete_resource", handler.DeleteResourceHandler)
+	mux.HandleFunc("GET /resources", handler.GetResourcesHandler)
+	mux.HandleFunc("GET /resource_availability", handler.GetResourceAvailabilityHandler)
+	mux.HandleFunc("POST /review_booking", handler.ReviewBookingHandler)
+	mux.HandleFunc("POST /create_appointment_type", handler.CreateAppointmentTypeHandler)
+	mux.HandleFunc("POST /delete_appointment_type", handler.DeleteAppointmentTypeHandler)
+	mux.HandleFunc("GET /appointment_types", handler.GetAppointmentTypesHandler)
+	mux.HandleFunc("POST /create_task", handler.CreateTaskHandler)
+	mux.HandleFunc("POST /complete_task", handler.CompleteTaskHandler)
+	mux.HandleFunc("POST /reopen_task", handler.ReopenTaskHandler)
+	mux.HandleFunc("POST /delete_task", handler.DeleteTaskHandler)
+	mux.HandleFunc("GET /tasks", handler.GetTasksHandler)
+	mux.HandleFunc("GET /search", handler.SearchHandler)
+	mux.HandleFunc("GET /tags", handler.GetTagsHandler)
+	mux.HandleFunc("GET /events_near", handler.GetEventsNearHandler)
+	mux.HandleFunc("GET /events_in_area", handler.GetEventsInAreaHandler)
+	mux.HandleFunc("POST /upload_attachment", handler.UploadAttachmentHandler)
+	mux.HandleFunc("POST /delete_attachment", handler.DeleteAttachmentHandler)
+	mux.HandleFunc("GET /attachment", handler.GetAttachmentHandler)
+	mux.HandleFunc("POST /define_field", handler.DefineFieldHandler)
+	mux.HandleFunc("POST /delete_field", handler.DeleteFieldHandler)
+	mux.HandleFunc("GET /fields", handler.GetFieldsHandler)
+	mux.HandleFunc("POST /restore_event", handler.RestoreHandler)
+	mux.HandleFunc("GET /trash", handler.GetTrashHandler)
+
+	var jwt *auth.JWTVerifier
+	var err error
+	if cfg.JWTJWKSPath != "" {
+		jwt, err = auth.NewJWTVerifier(auth.JWTConfig{
+			JWKSPath:  cfg.JWTJWKSPath,
+			Audience:  cfg.JWTAudience,
+			Issuer:    cfg.JWTIssuer,
+			UserClaim: cfg.JWTUserClaim,
+			Leeway:    cfg.JWTLeeway,
+		})
+		if err != nil {
+			log.Fatalf("jwt config error: %v\n", err)
+		}
+	}
+
+	authenticator, err := auth.NewAuthenticator(cfg.APIKeys, cfg.AuthGrants, jwt)
+	if err != nil {
+		log.Fatalf("auth config error: %v\n", err)
+	}
+
+	// Booking pages are public, everything else requires authentication
+	// unless it is turned off explicitly.
+	var protected http.Handler = mux
+	switch {
+	case cfg.AuthDisabled:
+		log.Println("authentication is disabled, requests act on the user_id they name")
+	case !authenticator.Enabled():
+		log.Fatalf("auth config error: no api_keys or jwt_jwks_path configured, set auth_disabled to allow open access\n")
+	default:
+		protected = auth.Middleware(mux, authenticator)
+	}
+	root := http.NewServeMux()
+	root.Handle("/", protected)
+	root.HandleFunc("GET /booking_page", handler.GetBookingPageHandler)
+	root.HandleFunc("POST /book_appointment", handler.BookAppointmentHandler)
 
 	httpServer := &http.Server{
 		Addr:    ":" + cfg.Port,
-		Handler: logger.Middleware(mux, cfg.PathLog),
+		Handler: logger.Middleware(root, cfg.PathLog),
+	}
+	httpServer.RegisterOnShutdown(pubsub.Close)
+
+	webhook.AllowPrivateTargets(cfg.WebhookAllowPrivate)
+	err = webhook.Init(cfg.WebhookStore)
+	if err != nil {
+		log.Fatalf("webhook store error: %v\n", err)
 	}
 
+	err = blob.Init(cfg.AttachmentDir, cfg.AttachmentMaxSize)
+	if err != nil {
+		log.Fatalf("attachment store error: %v\n", err)
+	}
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workers.Go(func() { webhook.Run(workersCtx) })
+
+	var sender *mail.Sender
+	if cfg.SMTPHost != "" {
+		sender = mail.NewSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+		service.SetMailSender(sender)
+	}
+
+	notifiers := scheduler.Notifiers{scheduler.LogNotifier{}}
+	if cfg.ReminderWebhook != "" {
+		notifiers = append(notifiers, scheduler.WebhookNotifier{URL: cfg.ReminderWebhook})
+	}
+	if sender != nil {
+		notifiers = append(notifiers, scheduler.EmailNotifier{Sender: sender, Recipient: service.UserEmail})
+	}
+	reminders := scheduler.New(notifiers)
+	workers.Go(func() { reminders.Run(workersCtx) })
+
+	channels := map[string]digest.Channel{service.DigestWebhook: digest.WebhookChannel{}}
+	if sender != nil {
+		channels[service.DigestEmail] = digest.EmailChannel{Sender: sender}
+	}
+	if cfg.DigestDir != "" {
+		channels[service.DigestFile] = digest.FileChannel{Dir: cfg.DigestDir}
+	}
+	digests := digest.NewJob(channels)
+	workers.Go(func() { digests.Run(workersCtx) })
+
+	service.SetTrashRetention(cfg.TrashRetention)
+	purger := trash.NewPurger()
+	workers.Go(func() { purger.Run(workersCtx) })
+
 	serverError := make(chan error, 1)
 	log.Printf("starting http server on port %s\n", httpServer.Addr)
 	go func() {
@@ -56,9 +198,13 @@ func run(cfg *config.Config) {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
-	err := httpServer.Shutdown(ctx)
+	err = httpServer.Shutdown(ctx)
 	if err != nil {
 		log.Fatalf("http server shutdown error: %s\n", err)
 	}
 	log.Println("http server shutdown complete")
+
+	stopWorkers()
+	workers.Wait()
+	log.Println("background workers stopped")
 }
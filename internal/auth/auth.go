@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"http-calendar/internal/models"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type Principal struct {
+	UserID uint64
+	grants map[uint64]bool
+}
+
+// CanActFor reports whether the principal may access the calendar of owner.
+func (p Principal) CanActFor(owner uint64) bool {
+	return p.UserID == owner || p.grants[owner]
+}
+
+type contextKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
+
+// Authenticator resolves API keys and bearer tokens to principals.
+type Authenticator struct {
+	keys   map[[sha256.Size]byte]uint64
+	grants map[uint64]map[uint64]bool
+	jwt    *JWTVerifier
+}
+
+// NewAuthenticator builds an authenticator from API keys mapped to user IDs
+// and grants in "grantee:owner" form. Bearer JWTs are accepted when jwt is
+// not nil.
+func NewAuthenticator(apiKeys map[string]string, grants []string, jwt *JWTVerifier) (*Authenticator, error) {
+	a := &Authenticator{
+		keys:   make(map[[sha256.Size]byte]uint64),
+		grants: make(map[uint64]map[uint64]bool),
+		jwt:    jwt,
+	}
+	for key, userID := range apiKeys {
+		uID, err := strconv.ParseUint(userID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("api key of user %q: %w", userID, err)
+		}
+		if key == "" {
+			return nil, errors.New("empty api key")
+		}
+		a.keys[sha256.Sum256([]byte(key))] = uID
+	}
+	for _, grant := range grants {
+		grantee, owner, ok := strings.Cut(grant, ":")
+		if !ok {
+			return nil, fmt.Errorf("grant %q: expected grantee:owner", grant)
+		}
+		granteeID, err := strconv.ParseUint(grantee, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("grant %q: %w", grant, err)
+		}
+		ownerID, err := strconv.ParseUint(owner, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("grant %q: %w", grant, err)
+		}
+		if a.grants[granteeID] == nil {
+			a.grants[granteeID] = make(map[uint64]bool)
+		}
+		a.grants[granteeID][ownerID] = true
+	}
+	return a, nil
+}
+
+func (a *Authenticator) Enabled() bool {
+	return len(a.keys) > 0 || a.jwt != nil
+}
+
+// Authenticate resolves the credentials of the request.
+func (a *Authenticator) Authenticate(r *http.Request) (Principal, error) {
+	token := r.Header.Get("X-API-Key")
+	if token == "" {
+		scheme, value, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			token = strings.TrimSpace(value)
+		}
+		if a.jwt != nil && looksLikeJWT(token) {
+			userID, err := a.jwt.Verify(token)
+			if err != nil {
+				return Principal{}, err
+			}
+			return Principal{UserID: userID, grants: a.grants[userID]}, nil
+		}
+	}
+	if token == "" {
+		return Principal{}, models.ErrUnauthorized
+	}
+
+	// Keys are looked up by hash so the comparison does not leak their
+	// content through timing.
+	userID, ok := a.keys[sha256.Sum256([]byte(token))]
+	if !ok {
+		return Principal{}, models.ErrUnauthorized
+	}
+	return Principal{UserID: userID, grants: a.grants[userID]}, nil
+}
+
+// Middleware rejects unauthenticated requests and stores the principal in
+// the request context. Without configured credentials every request is
+// rejected; open access has to be asked for by not using the middleware.
+func Middleware(next http.Handler, a *Authenticator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := a.Authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="http-calendar"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			err = json.NewEncoder(w).Encode(struct {
+				Error string `json:"error"`
+			}{err.Error()})
+			if err != nil {
+				log.Printf("Failed encode response: %v\n", err)
+			}
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+	})
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestAuthenticator(t *testing.T) *Authenticator {
+	t.Helper()
+	a, err := NewAuthenticator(map[string]string{"alice-key": "1", "bob-key": "2"}, []string{"2:1"}, nil)
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	return a
+}
+
+func TestAuthenticate(t *testing.T) {
+	a := newTestAuthenticator(t)
+
+	tests := []struct {
+		name    string
+		header  string
+		value   string
+		want    uint64
+		wantErr error
+	}{
+		{name: "api key", header: "X-API-Key", value: "alice-key", want: 1},
+		{name: "bearer token", header: "Authorization", value: "Bearer bob-key", want: 2},
+		{name: "lowercase scheme", header: "Authorization", value: "bearer bob-key", want: 2},
+		{name: "unknown key", header: "X-API-Key", value: "mallory-key", wantErr: models.ErrUnauthorized},
+		{name: "basic auth", header: "Authorization", value: "Basic YWxpY2U6a2V5", wantErr: models.ErrUnauthorized},
+		{name: "no credentials", wantErr: models.ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/events_for_day", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			p, err := a.Authenticate(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && p.UserID != tt.want {
+				t.Errorf("Authenticate() user = %v, want %v", p.UserID, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanActFor(t *testing.T) {
+	a := newTestAuthenticator(t)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-API-Key", "bob-key")
+	bob, err := a.Authenticate(r)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if !bob.CanActFor(2) || !bob.CanActFor(1) {
+		t.Errorf("Bob should act for himself and Alice")
+	}
+	if bob.CanActFor(3) {
+		t.Errorf("Bob should not act for user 3")
+	}
+
+	r.Header.Set("X-API-Key", "alice-key")
+	alice, err := a.Authenticate(r)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if alice.CanActFor(2) {
+		t.Errorf("Grants should not be symmetric")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	a := newTestAuthenticator(t)
+
+	var got Principal
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = FromContext(r.Context())
+	})
+	h := Middleware(next, a)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without credentials, got %d", w.Code)
+	}
+	if w.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("Expected WWW-Authenticate header")
+	}
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer alice-key")
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || got.UserID != 1 {
+		t.Errorf("Expected principal 1, got %d with status %d", got.UserID, w.Code)
+	}
+}
+
+func TestMiddleware_Unconfigured(t *testing.T) {
+	a, err := NewAuthenticator(nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+
+	var called bool
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}), a)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/?user_id=1", nil)
+	r.Header.Set("X-API-Key", "any-key")
+	h.ServeHTTP(w, r)
+	if called || w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without configured credentials, got %d", w.Code)
+	}
+}
+
+func TestNewAuthenticator_Invalid(t *testing.T) {
+	if _, err := NewAuthenticator(map[string]string{"key": "alice"}, nil, nil); err == nil {
+		t.Errorf("Expected error for non-numeric user ID")
+	}
+	if _, err := NewAuthenticator(nil, []string{"2-1"}, nil); err == nil {
+		t.Errorf("Expected error for malformed grant")
+	}
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+
+	reloadInterval = 5 * time.Second
+)
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+}
+
+type verificationKey struct {
+	alg string
+	key any
+}
+
+// KeySet holds the verification keys of a local JWKS file. The file is
+// re-read when it changes, so keys can be rotated without a restart.
+type KeySet struct {
+	path string
+
+	mu        sync.Mutex
+	keys      map[string]verificationKey
+	modTime   time.Time
+	size      int64
+	checkedAt time.Time
+}
+
+func LoadKeySet(path string) (*KeySet, error) {
+	ks := &KeySet{path: path}
+	if err := ks.reload(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Key returns the key with the given id. A token without kid matches the
+// only key of the set.
+func (ks *KeySet) Key(kid string) (verificationKey, bool) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	key, ok := ks.lookup(kid)
+	if !ok || time.Since(ks.checkedAt) > reloadInterval {
+		if err := ks.reloadIfChanged(); err != nil {
+			log.Printf("Error reloading JWKS %s: %v\n", ks.path, err)
+		}
+		key, ok = ks.lookup(kid)
+	}
+	return key, ok
+}
+
+func (ks *KeySet) lookup(kid string) (verificationKey, bool) {
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+func (ks *KeySet) reloadIfChanged() error {
+	ks.checkedAt = time.Now()
+	info, err := os.Stat(ks.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(ks.modTime) && info.Size() == ks.size {
+		return nil
+	}
+	return ks.reload()
+}
+
+// reload replaces the keys with the content of the file. On error the
+// previous keys stay in place.
+func (ks *KeySet) reload() error {
+	info, err := os.Stat(ks.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(ks.path)
+	if err != nil {
+		return err
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err = json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("parse jwks: %w", err)
+	}
+
+	keys := make(map[string]verificationKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := parseJWK(k)
+		if err != nil {
+			return fmt.Errorf("jwk %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+
+	ks.keys = keys
+	ks.modTime = info.ModTime()
+	ks.size = info.Size()
+	ks.checkedAt = time.Now()
+	return nil
+}
+
+func parseJWK(k jwk) (verificationKey, error) {
+	switch k.Kty {
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil || len(secret) == 0 {
+			return verificationKey{}, errors.New("invalid symmetric key")
+		}
+		return verificationKey{alg: AlgHS256, key: secret}, nil
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return verificationKey{}, errors.New("invalid modulus")
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return verificationKey{}, errors.New("invalid exponent")
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if pub.N.BitLen() < 2048 {
+			return verificationKey{}, errors.New("rsa key shorter than 2048 bits")
+		}
+		return verificationKey{alg: AlgRS256, key: pub}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return verificationKey{}, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return verificationKey{}, errors.New("invalid ed25519 key")
+		}
+		return verificationKey{alg: AlgEdDSA, key: ed25519.PublicKey(x)}, nil
+	default:
+		return verificationKey{}, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
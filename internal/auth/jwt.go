@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"http-calendar/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type JWTConfig struct {
+	JWKSPath  string
+	Audience  string
+	Issuer    string
+	UserClaim string
+	Leeway    time.Duration
+}
+
+// JWTVerifier validates bearer JWTs against a local key set and maps a
+// claim to the calendar user ID.
+type JWTVerifier struct {
+	keys      *KeySet
+	audience  string
+	issuer    string
+	userClaim string
+	leeway    time.Duration
+	now       func() time.Time
+}
+
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	keys, err := LoadKeySet(cfg.JWKSPath)
+	if err != nil {
+		return nil, err
+	}
+	userClaim := cfg.UserClaim
+	if userClaim == "" {
+		userClaim = "sub"
+	}
+	return &JWTVerifier{
+		keys:      keys,
+		audience:  cfg.Audience,
+		issuer:    cfg.Issuer,
+		userClaim: userClaim,
+		leeway:    cfg.Leeway,
+		now:       time.Now,
+	}, nil
+}
+
+func looksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+func invalidToken(reason string) error {
+	return fmt.Errorf("%w: %s", models.ErrUnauthorized, reason)
+}
+
+// Verify checks the signature and the time and audience claims of token and
+// returns the user it was issued for.
+func (v *JWTVerifier) Verify(token string) (uint64, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, invalidToken("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return 0, invalidToken("malformed header")
+	}
+	key, ok := v.keys.Key(header.Kid)
+	if !ok {
+		return 0, invalidToken("unknown key")
+	}
+	// The algorithm is dictated by the key, never by the token, so an RSA
+	// public key can not be abused as an HMAC secret.
+	if header.Alg != key.alg {
+		return 0, invalidToken("unexpected algorithm")
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return 0, invalidToken("malformed signature")
+	}
+	if !verifySignature(key, []byte(parts[0]+"."+parts[1]), sig) {
+		return 0, invalidToken("invalid signature")
+	}
+
+	var claims map[string]any
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return 0, invalidToken("malformed claims")
+	}
+	if err = v.checkClaims(claims); err != nil {
+		return 0, err
+	}
+	return userFromClaim(claims[v.userClaim])
+}
+
+func verifySignature(key verificationKey, signed, sig []byte) bool {
+	switch key.alg {
+	case AlgHS256:
+		mac := hmac.New(sha256.New, key.key.([]byte))
+		mac.Write(signed)
+		return hmac.Equal(mac.Sum(nil), sig)
+	case AlgRS256:
+		digest := sha256.Sum256(signed)
+		return rsa.VerifyPKCS1v15(key.key.(*rsa.PublicKey), crypto.SHA256, digest[:], sig) == nil
+	case AlgEdDSA:
+		return ed25519.Verify(key.key.(ed25519.PublicKey), signed, sig)
+	}
+	return false
+}
+
+func (v *JWTVerifier) checkClaims(claims map[string]any) error {
+	now := v.now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return invalidToken("missing exp")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(v.leeway)) {
+		return invalidToken("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(v.leeway).Before(time.Unix(int64(nbf), 0)) {
+		return invalidToken("token not valid yet")
+	}
+
+	if v.issuer != "" && claims["iss"] != v.issuer {
+		return invalidToken("unexpected issuer")
+	}
+	if v.audience != "" && !hasAudience(claims["aud"], v.audience) {
+		return invalidToken("unexpected audience")
+	}
+	return nil
+}
+
+func hasAudience(aud any, want string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == want
+	case []any:
+		for _, a := range aud {
+			if a == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func userFromClaim(value any) (uint64, error) {
+	switch value := value.(type) {
+	case string:
+		uID, err := strconv.ParseUint(value, 10, 64)
+		if err == nil {
+			return uID, nil
+		}
+	case float64:
+		if value >= 0 && value == float64(uint64(value)) {
+			return uint64(value), nil
+		}
+	}
+	return 0, invalidToken("user claim is not a user id")
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"http-calendar/internal/models"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var (
+	hmacSecret = []byte("0123456789abcdef0123456789abcdef")
+	rsaKey     *rsa.PrivateKey
+	edKey      ed25519.PrivateKey
+)
+
+func init() {
+	var err error
+	rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	_, edKey, err = ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func writeJWKS(t *testing.T, path string, keys ...map[string]string) {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if err = os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func hmacJWK(kid string) map[string]string {
+	return map[string]string{"kty": "oct", "kid": kid, "k": b64(hmacSecret)}
+}
+
+func rsaJWK(kid string) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"n":   b64(rsaKey.N.Bytes()),
+		"e":   b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+	}
+}
+
+func edJWK(kid string) map[string]string {
+	return map[string]string{"kty": "OKP", "crv": "Ed25519", "kid": kid, "x": b64(edKey.Public().(ed25519.PublicKey))}
+}
+
+func sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+
+	var sig []byte
+	switch alg {
+	case AlgHS256:
+		mac := hmac.New(sha256.New, hmacSecret)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case AlgRS256:
+		digest := sha256.Sum256([]byte(signed))
+		var err error
+		sig, err = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("SignPKCS1v15() error = %v", err)
+		}
+	case AlgEdDSA:
+		sig = ed25519.Sign(edKey, []byte(signed))
+	}
+	return signed + "." + b64(sig)
+}
+
+var now = time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+func newTestVerifier(t *testing.T) (*JWTVerifier, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, hmacJWK("hs"), rsaJWK("rs"), edJWK("ed"))
+
+	v, err := NewJWTVerifier(JWTConfig{JWKSPath: path, Audience: "calendar", Issuer: "gateway", UserClaim: "uid", Leeway: time.Minute})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier() error = %v", err)
+	}
+	v.now = func() time.Time { return now }
+	return v, path
+}
+
+func claims(overrides map[string]any) map[string]any {
+	c := map[string]any{
+		"iss": "gateway",
+		"aud": "calendar",
+		"uid": "42",
+		"exp": now.Add(time.Hour).Unix(),
+		"nbf": now.Add(-time.Hour).Unix(),
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(c, k)
+			continue
+		}
+		c[k] = v
+	}
+	return c
+}
+
+func TestVerify(t *testing.T) {
+	v, _ := newTestVerifier(t)
+
+	tests := []struct {
+		name    string
+		token   string
+		want    uint64
+		wantErr bool
+	}{
+		{name: "HS256", token: sign(t, AlgHS256, "hs", claims(nil)), want: 42},
+		{name: "RS256", token: sign(t, AlgRS256, "rs", claims(nil)), want: 42},
+		{name: "EdDSA", token: sign(t, AlgEdDSA, "ed", claims(nil)), want: 42},
+		{name: "numeric user claim", token: sign(t, AlgEdDSA, "ed", claims(map[string]any{"uid": 7})), want: 7},
+		{name: "audience list", token: sign(t, AlgEdDSA, "ed", claims(map[string]any{"aud": []string{"other", "calendar"}})), want: 42},
+		{name: "expired within leeway", token: sign(t, AlgEdDSA, "ed", claims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()})), want: 42},
+		{name: "expired", token: sign(t, AlgEdDSA, "ed", claims(map[string]any{"exp": now.Add(-time.Hour).Unix()})), wantErr: true},
+		{name: "missing exp", token: sign(t, AlgEdDSA, "ed", claims(map[string]any{"exp": nil})), wantErr: true},
+		{name: "not valid yet", token: sign(t, AlgEdDSA, "ed", claims(map[string]any{"nbf": now.Add(time.Hour).Unix()})), wantErr: true},
+		{name: "wrong audience", token: sign(t, AlgEdDSA, "ed", claims(map[string]any{"aud": "billing"})), wantErr: true},
+		{name: "wrong issuer", token: sign(t, AlgEdDSA, "ed", claims(map[string]any{"iss": "mallory"})), wantErr: true},
+		{name: "missing user claim", token: sign(t, AlgEdDSA, "ed", claims(map[string]any{"uid": nil})), wantErr: true},
+		{name: "non numeric user claim", token: sign(t, AlgEdDSA, "ed", claims(map[string]any{"uid": "alice"})), wantErr: true},
+		{name: "unknown kid", token: sign(t, AlgEdDSA, "other", claims(nil)), wantErr: true},
+		{name: "algorithm mismatch", token: sign(t, AlgHS256, "rs", claims(nil)), wantErr: true},
+		{name: "alg none", token: b64([]byte(`{"alg":"none","kid":"hs"}`)) + "." + b64([]byte(`{"uid":"42"}`)) + ".", wantErr: true},
+		{name: "tampered", token: sign(t, AlgEdDSA, "ed", claims(nil))[:40] + "x" + sign(t, AlgEdDSA, "ed", claims(nil))[41:], wantErr: true},
+		{name: "malformed", token: "a.b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Verify(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, models.ErrUnauthorized) {
+				t.Errorf("Verify() error = %v, want %v", err, models.ErrUnauthorized)
+			}
+			if got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	v, path := newTestVerifier(t)
+
+	old := sign(t, AlgEdDSA, "ed", claims(nil))
+	if _, err := v.Verify(old); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	// Rotate: the Ed25519 key is retired and a new RSA key id is published.
+	writeJWKS(t, path, rsaJWK("rs-2"))
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	if _, err := v.Verify(sign(t, AlgRS256, "rs-2", claims(nil))); err != nil {
+		t.Errorf("Token signed with the new key rejected: %v", err)
+	}
+	if _, err := v.Verify(old); err == nil {
+		t.Errorf("Token signed with the retired key accepted")
+	}
+}
+
+func TestAuthenticate_JWT(t *testing.T) {
+	v, _ := newTestVerifier(t)
+	a, err := NewAuthenticator(map[string]string{"alice-key": "1"}, []string{"42:1"}, v)
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+sign(t, AlgRS256, "rs", claims(nil)))
+	p, err := a.Authenticate(r)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if p.UserID != 42 || !p.CanActFor(1) {
+		t.Errorf("Unexpected principal %+v", p)
+	}
+
+	r.Header.Set("Authorization", "Bearer alice-key")
+	if p, err = a.Authenticate(r); err != nil || p.UserID != 1 {
+		t.Errorf("API keys should keep working next to JWTs: %+v, %v", p, err)
+	}
+}
@@ -0,0 +1,195 @@
+// Package blob stores files on local disk under the SHA-256 hash of their
+// content, so that identical uploads share one file.
+package blob
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// sniffLength is the number of bytes http.DetectContentType looks at.
+const sniffLength = 512
+
+var (
+	ErrDisabled = errors.New("blob store is not configured")
+	ErrTooLarge = errors.New("blob is too large")
+	ErrNotFound = errors.New("blob not found")
+	ErrEmpty    = errors.New("blob is empty")
+)
+
+var store struct {
+	mu      sync.Mutex
+	dir     string
+	maxSize int64
+}
+
+// Info describes stored content.
+type Info struct {
+	Hash        string
+	Size        int64
+	ContentType string
+}
+
+// Init keeps blobs of at most maxSize bytes in dir. An empty dir disables
+// the store.
+func Init(dir string, maxSize int64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.dir, store.maxSize = dir, maxSize
+	if dir == "" {
+		return nil
+	}
+	return os.MkdirAll(filepath.Join(dir, "tmp"), 0755)
+}
+
+// MaxSize returns the size limit of blobs.
+func MaxSize() int64 {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.maxSize
+}
+
+// Upload is content written to a temporary file that is not in the store
+// until it is committed.
+type Upload struct {
+	Info
+	path string
+}
+
+// Write streams r to a temporary file, hashing it and sniffing its content
+// type. name is used for the type of archives and content that is not
+// recognized. Write fails with ErrTooLarge past the size limit.
+func Write(r io.Reader, name string) (*Upload, error) {
+	store.mu.Lock()
+	dir, maxSize := store.dir, store.maxSize
+	store.mu.Unlock()
+	if dir == "" {
+		return nil, ErrDisabled
+	}
+
+	file, err := os.CreateTemp(filepath.Join(dir, "tmp"), "upload-")
+	if err != nil {
+		return nil, err
+	}
+	upload := &Upload{path: file.Name()}
+	defer func() {
+		if err != nil {
+			upload.Discard()
+		}
+	}()
+
+	hash := sha256.New()
+	buffered := bufio.NewReaderSize(io.LimitReader(r, maxSize+1), sniffLength)
+	head, _ := buffered.Peek(sniffLength)
+	upload.ContentType = contentType(head, name)
+
+	upload.Size, err = io.Copy(io.MultiWriter(file, hash), buffered)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	switch {
+	case err != nil:
+		return nil, err
+	case upload.Size > maxSize:
+		err = ErrTooLarge
+		return nil, err
+	case upload.Size == 0:
+		err = ErrEmpty
+		return nil, err
+	}
+	upload.Hash = hex.EncodeToString(hash.Sum(nil))
+	return upload, nil
+}
+
+// contentType sniffs the type of content beginning with head. Archives
+// such as office documents and unknown content take the type of the
+// extension of name, when it has a known one.
+func contentType(head []byte, name string) string {
+	sniffed := http.DetectContentType(head)
+	if sniffed == "application/octet-stream" || sniffed == "application/zip" {
+		if byName := mime.TypeByExtension(filepath.Ext(name)); byName != "" {
+			return byName
+		}
+	}
+	return sniffed
+}
+
+// Commit moves the upload into the store. Content that is stored already
+// is kept and the upload dropped.
+func (u *Upload) Commit() error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	path := blobPath(store.dir, u.Hash)
+	if _, err := os.Stat(path); err == nil {
+		return os.Remove(u.path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.Rename(u.path, path)
+}
+
+// Discard removes the upload unless it was committed.
+func (u *Upload) Discard() {
+	if err := os.Remove(u.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Failed remove upload %s: %v\n", u.path, err)
+	}
+}
+
+// Open returns the content stored under hash.
+func Open(hash string) (*os.File, error) {
+	store.mu.Lock()
+	dir := store.dir
+	store.mu.Unlock()
+	if dir == "" {
+		return nil, ErrDisabled
+	}
+	if !validHash(hash) {
+		return nil, ErrNotFound
+	}
+	file, err := os.Open(blobPath(dir, hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Delete removes the content stored under hash, if any. Readers that
+// opened it before keep reading.
+func Delete(hash string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.dir == "" || !validHash(hash) {
+		return nil
+	}
+	err := os.Remove(blobPath(store.dir, hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// blobPath spreads blobs over directories named after the first byte of
+// their hash.
+func blobPath(dir, hash string) string {
+	return filepath.Join(dir, hash[:2], hash)
+}
+
+func validHash(hash string) bool {
+	if len(hash) != 2*sha256.Size {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
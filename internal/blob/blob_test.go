@@ -0,0 +1,84 @@
+package blob
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	if err := Init(dir, 1024); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	first := put(t, "%PDF-1.7 agenda", "agenda.pdf")
+	if first.ContentType != "application/pdf" || first.Size != 15 {
+		t.Errorf("Info = %+v", first.Info)
+	}
+	second := put(t, "%PDF-1.7 agenda", "copy.pdf")
+	if second.Hash != first.Hash {
+		t.Errorf("Same content stored as %s and %s", first.Hash, second.Hash)
+	}
+
+	file, err := Open(first.Hash)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	data, _ := io.ReadAll(file)
+	file.Close()
+	if string(data) != "%PDF-1.7 agenda" {
+		t.Errorf("Content = %q", data)
+	}
+
+	if err = Delete(first.Hash); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err = Open(first.Hash); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open() after delete error = %v, want %v", err, ErrNotFound)
+	}
+
+	if _, err = Write(strings.NewReader(strings.Repeat("x", 1025)), "big.txt"); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Write() error = %v, want %v", err, ErrTooLarge)
+	}
+	if _, err = Write(strings.NewReader(""), "empty.txt"); !errors.Is(err, ErrEmpty) {
+		t.Errorf("Write() error = %v, want %v", err, ErrEmpty)
+	}
+	left, _ := os.ReadDir(filepath.Join(dir, "tmp"))
+	if len(left) != 0 {
+		t.Errorf("Temporary files left: %d", len(left))
+	}
+	if _, err = Open("../../etc/passwd"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open() of a path error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestContentType(t *testing.T) {
+	for _, test := range []struct {
+		head, name, want string
+	}{
+		{"\x89PNG\r\n\x1a\n", "slides.pdf", "image/png"},
+		{"\x00\x01\x02", "notes.pdf", "application/pdf"},
+		{"PK\x03\x04", "archive", "application/zip"},
+		{"\x00\x01\x02", "data", "application/octet-stream"},
+	} {
+		if got := contentType([]byte(test.head), test.name); got != test.want {
+			t.Errorf("contentType(%q, %q) = %q, want %q", test.head, test.name, got, test.want)
+		}
+	}
+}
+
+func put(t *testing.T, content, name string) *Upload {
+	t.Helper()
+	upload, err := Write(strings.NewReader(content), name)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err = upload.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	return upload
+}
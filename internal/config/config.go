@@ -3,6 +3,7 @@ package config
 import (
 	"flag"
 	"log"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -10,6 +11,34 @@ import (
 type Config struct {
 	Port    string `yaml:"port" env:"PORT" default:"8080" env-default:"8080"`
 	PathLog string `yaml:"path_log" env:"PATH_LOG" default:"/dev/null" env-default:"/dev/null"`
+
+	WebhookStore    string `yaml:"webhook_store" env:"WEBHOOK_STORE"`
+	ReminderWebhook string `yaml:"reminder_webhook" env:"REMINDER_WEBHOOK"`
+
+	WebhookAllowPrivate bool `yaml:"webhook_allow_private" env:"WEBHOOK_ALLOW_PRIVATE"`
+
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     string `yaml:"smtp_port" env:"SMTP_PORT" env-default:"25"`
+	SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD"`
+	SMTPFrom     string `yaml:"smtp_from" env:"SMTP_FROM" env-default:"calendar@localhost"`
+
+	DigestDir string `yaml:"digest_dir" env:"DIGEST_DIR"`
+
+	AttachmentDir     string `yaml:"attachment_dir" env:"ATTACHMENT_DIR"`
+	AttachmentMaxSize int64  `yaml:"attachment_max_size" env:"ATTACHMENT_MAX_SIZE" env-default:"26214400"`
+
+	TrashRetention time.Duration `yaml:"trash_retention" env:"TRASH_RETENTION" env-default:"720h"`
+
+	APIKeys      map[string]string `yaml:"api_keys" env:"API_KEYS"`
+	AuthGrants   []string          `yaml:"auth_grants" env:"AUTH_GRANTS"`
+	AuthDisabled bool              `yaml:"auth_disabled" env:"AUTH_DISABLED"`
+
+	JWTJWKSPath  string        `yaml:"jwt_jwks_path" env:"JWT_JWKS_PATH"`
+	JWTAudience  string        `yaml:"jwt_audience" env:"JWT_AUDIENCE"`
+	JWTIssuer    string        `yaml:"jwt_issuer" env:"JWT_ISSUER"`
+	JWTUserClaim string        `yaml:"jwt_user_claim" env:"JWT_USER_CLAIM" env-default:"sub"`
+	JWTLeeway    time.Duration `yaml:"jwt_leeway" env:"JWT_LEEWAY" env-default:"30s"`
 }
 
 func NewConfig() *Config {
@@ -19,13 +48,13 @@ func NewConfig() *Config {
 	flag.StringVar(&cfgPath, "config", "", "path to config file")
 	flag.Parse()
 
-	var err error
+	// A config file that was asked for but can not be read would leave the
+	// server running without its settings.
 	if cfgPath != "" {
-		err = cleanenv.ReadConfig(cfgPath, &cfg)
-	} else {
-		err = cleanenv.ReadEnv(&cfg)
-	}
-	if err != nil {
+		if err := cleanenv.ReadConfig(cfgPath, &cfg); err != nil {
+			log.Fatalf("Error loading config %s: %v", cfgPath, err)
+		}
+	} else if err := cleanenv.ReadEnv(&cfg); err != nil {
 		log.Printf("Error loading config: %v", err)
 	}
 
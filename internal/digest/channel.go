@@ -0,0 +1,80 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"http-calendar/internal/mail"
+	"http-calendar/internal/models"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Channel delivers a digest to a user.
+type Channel interface {
+	Deliver(ctx context.Context, user models.User, d *models.Digest) error
+}
+
+// WebhookChannel posts the digest as JSON to the target URL of the user.
+type WebhookChannel struct {
+	Client *http.Client
+}
+
+func (c WebhookChannel) Deliver(ctx context.Context, user models.User, d *models.Digest) error {
+	body, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, user.Digest.Target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = resp.Body.Close(); err != nil {
+			log.Printf("Error closing digest webhook response: %v\n", err)
+		}
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("digest webhook: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// EmailChannel mails the digest to the address of the user.
+type EmailChannel struct {
+	Sender *mail.Sender
+}
+
+func (c EmailChannel) Deliver(ctx context.Context, user models.User, d *models.Digest) error {
+	msg, err := mail.AgendaMessage(user.Email, Title(d), d.Events)
+	if err != nil {
+		return err
+	}
+	return c.Sender.Send(ctx, msg)
+}
+
+// FileChannel drops the digest as a Markdown file into Dir.
+type FileChannel struct {
+	Dir string
+}
+
+func (c FileChannel) Deliver(_ context.Context, user models.User, d *models.Digest) error {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d-%s-%s.md", user.UserID, d.Period, d.From.Format("2006-01-02"))
+	return os.WriteFile(filepath.Join(c.Dir, name), Markdown(d), 0644)
+}
@@ -0,0 +1,134 @@
+package digest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"http-calendar/internal/models"
+	"http-calendar/internal/service"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+var ErrInvalidFormat = errors.New("invalid format")
+
+// Build assembles the digest of userID for the day or the week starting at
+// date.
+func Build(userID uint64, date time.Time, period string, opts ...service.QueryOption) (*models.Digest, error) {
+	get := service.GetEventsForDay
+	days := 1
+	switch period {
+	case models.PeriodDay:
+	case models.PeriodWeek:
+		get = service.GetEventsForWeek
+		days = 7
+	default:
+		return nil, models.ErrInvalidDigest
+	}
+
+	events, err := get(strconv.FormatUint(userID, 10), date.Format(service.DateFormat), opts...)
+	if errors.Is(err, models.ErrUserNotFound) {
+		events = nil
+	} else if err != nil {
+		return nil, err
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].Start().Equal(events[j].Start()) {
+			return events[i].Start().Before(events[j].Start())
+		}
+		return events[i].EventID < events[j].EventID
+	})
+
+	from := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	return &models.Digest{
+		UserID: userID,
+		Period: period,
+		From:   from,
+		To:     from.AddDate(0, 0, days),
+		Events: events,
+	}, nil
+}
+
+func Title(d *models.Digest) string {
+	if d.Period == models.PeriodWeek {
+		return "Agenda for the week of " + d.From.Format("Mon, 02 Jan 2006")
+	}
+	return "Agenda for " + d.From.Format("Mon, 02 Jan 2006")
+}
+
+func when(event models.Event) string {
+	if event.Time == "" {
+		return event.Date.Format("Mon 02 Jan") + ", all day"
+	}
+	return event.Start().Format("Mon 02 Jan 15:04")
+}
+
+func Markdown(d *models.Digest) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", Title(d))
+	if len(d.Events) == 0 {
+		b.WriteString("No events.\n")
+	}
+	for _, event := range d.Events {
+		fmt.Fprintf(&b, "- **%s** %s\n", when(event), escapeMarkdown(event.Title))
+		if event.Description != "" {
+			fmt.Fprintf(&b, "  %s\n", escapeMarkdown(event.Description))
+		}
+	}
+	return []byte(b.String())
+}
+
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "#", `\#`, "\n", " ").Replace(s)
+}
+
+var htmlTemplate = template.Must(template.New("digest").Funcs(template.FuncMap{"when": when}).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+{{- if .Events}}
+<ul>
+{{- range .Events}}
+<li><strong>{{when .}}</strong> {{.Title}}{{with .Description}}<br>{{.}}{{end}}</li>
+{{- end}}
+</ul>
+{{- else}}
+<p>No events.</p>
+{{- end}}
+</body>
+</html>
+`))
+
+func HTML(d *models.Digest) ([]byte, error) {
+	var buf bytes.Buffer
+	err := htmlTemplate.Execute(&buf, struct {
+		Title  string
+		Events []models.Event
+	}{Title(d), d.Events})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Render returns the digest in format together with its content type.
+func Render(d *models.Digest, format string) ([]byte, string, error) {
+	switch format {
+	case "", FormatMarkdown:
+		return Markdown(d), "text/markdown; charset=utf-8", nil
+	case FormatHTML:
+		data, err := HTML(d)
+		return data, "text/html; charset=utf-8", err
+	default:
+		return nil, "", ErrInvalidFormat
+	}
+}
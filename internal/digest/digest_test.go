@@ -0,0 +1,169 @@
+package digest
+
+import (
+	"context"
+	"http-calendar/internal/models"
+	"http-calendar/internal/service"
+	"http-calendar/internal/storage"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuild(t *testing.T) {
+	storage.Clear()
+
+	for _, e := range []struct{ date, at, title string }{
+		{"2024-01-15", "14:00", "Review"},
+		{"2024-01-15", "09:00", "Standup"},
+		{"2024-01-17", "", "Offsite"},
+		{"2024-01-25", "", "Next week"},
+	} {
+		if _, err := service.CreateEvent("1", e.date, e.title, "", service.WithTime(e.at)); err != nil {
+			t.Fatalf("Failed to create test event: %v", err)
+		}
+	}
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	day, err := Build(1, date, models.PeriodDay)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if len(day.Events) != 2 || day.Events[0].Title != "Standup" || day.Events[1].Title != "Review" {
+		t.Errorf("Unexpected day digest %+v", day.Events)
+	}
+
+	week, err := Build(1, date, models.PeriodWeek)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if len(week.Events) != 3 {
+		t.Errorf("Expected 3 events in the week, got %d", len(week.Events))
+	}
+	if !week.To.Equal(date.AddDate(0, 0, 7)) {
+		t.Errorf("Week digest ends at %v", week.To)
+	}
+
+	empty, err := Build(2, date, models.PeriodDay)
+	if err != nil {
+		t.Fatalf("Build() for user without events error = %v", err)
+	}
+	if len(empty.Events) != 0 {
+		t.Errorf("Expected no events, got %d", len(empty.Events))
+	}
+
+	if _, err = Build(1, date, "month"); err == nil {
+		t.Errorf("Build() expected error for invalid period")
+	}
+}
+
+func TestRender(t *testing.T) {
+	d := &models.Digest{
+		Period: models.PeriodDay,
+		From:   time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		Events: []models.Event{{Date: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), Time: "09:00", Title: "<Standup> *daily*"}},
+	}
+
+	md, contentType, err := Render(d, FormatMarkdown)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !strings.HasPrefix(contentType, "text/markdown") {
+		t.Errorf("Content type = %v", contentType)
+	}
+	if !strings.Contains(string(md), "# Agenda for Mon, 15 Jan 2024") || !strings.Contains(string(md), `- **Mon 15 Jan 09:00** <Standup> \*daily\*`) {
+		t.Errorf("Unexpected markdown:\n%s", md)
+	}
+
+	html, _, err := Render(d, FormatHTML)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !strings.Contains(string(html), "&lt;Standup&gt;") {
+		t.Errorf("HTML is not escaped:\n%s", html)
+	}
+
+	if _, _, err = Render(d, "pdf"); err != ErrInvalidFormat {
+		t.Errorf("Render() error = %v, want %v", err, ErrInvalidFormat)
+	}
+}
+
+func TestJobDue(t *testing.T) {
+	// 07:30 UTC is 08:30 in Berlin on a Monday.
+	now := time.Date(2024, 1, 15, 7, 30, 0, 0, time.UTC)
+	j := NewJob(nil)
+	j.now = func() time.Time { return now }
+
+	tests := []struct {
+		name string
+		user models.User
+		want bool
+	}{
+		{
+			name: "disabled",
+			user: models.User{UserID: 1},
+		},
+		{
+			name: "local time passed",
+			user: models.User{UserID: 1, TimeZone: "Europe/Berlin", Digest: models.DigestSettings{Period: models.PeriodDay, Time: "08:00"}},
+			want: true,
+		},
+		{
+			name: "local time not yet reached",
+			user: models.User{UserID: 1, TimeZone: "America/New_York", Digest: models.DigestSettings{Period: models.PeriodDay, Time: "08:00"}},
+		},
+		{
+			name: "weekly on monday",
+			user: models.User{UserID: 1, Digest: models.DigestSettings{Period: models.PeriodWeek, Time: "07:00"}},
+			want: true,
+		},
+		{
+			name: "weekly on sunday",
+			user: models.User{UserID: 1, TimeZone: "America/Los_Angeles", Digest: models.DigestSettings{Period: models.PeriodWeek, Time: "07:00"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := j.due(tt.user); got != tt.want {
+				t.Errorf("due() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobDeliversOncePerDay(t *testing.T) {
+	storage.Clear()
+	dir := t.TempDir()
+
+	if _, err := service.CreateEvent("1", "2024-01-15", "Standup", ""); err != nil {
+		t.Fatalf("Failed to create test event: %v", err)
+	}
+	_, err := service.UpdateUser("1", service.WithDigest(models.PeriodDay, "08:00", service.DigestFile, ""))
+	if err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+
+	j := NewJob(map[string]Channel{service.DigestFile: FileChannel{Dir: dir}})
+	j.now = func() time.Time { return time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC) }
+	j.deliverDue(context.Background())
+
+	path := filepath.Join(dir, "1-day-2024-01-15.md")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Digest file not written: %v", err)
+	}
+	if !strings.Contains(string(data), "Standup") {
+		t.Errorf("Unexpected digest:\n%s", data)
+	}
+
+	if err = os.Remove(path); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	j.deliverDue(context.Background())
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Digest delivered twice on the same day")
+	}
+}
@@ -0,0 +1,102 @@
+package digest
+
+import (
+	"context"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"log"
+	"time"
+)
+
+const (
+	checkInterval  = time.Minute
+	deliverTimeout = 30 * time.Second
+)
+
+// Job delivers the scheduled digests of every user once their local digest
+// time has passed. Weekly digests go out on Mondays.
+type Job struct {
+	channels map[string]Channel
+	now      func() time.Time
+	sent     map[uint64]string
+}
+
+func NewJob(channels map[string]Channel) *Job {
+	return &Job{
+		channels: channels,
+		now:      time.Now,
+		sent:     make(map[uint64]string),
+	}
+}
+
+func (j *Job) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		j.deliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *Job) deliverDue(ctx context.Context) {
+	for _, user := range storage.GetUsers() {
+		if ctx.Err() != nil {
+			return
+		}
+		date, ok := j.due(user)
+		if !ok {
+			continue
+		}
+		channel, ok := j.channels[user.Digest.Channel]
+		if !ok {
+			log.Printf("digest channel %q of user %d is not configured\n", user.Digest.Channel, user.UserID)
+			continue
+		}
+
+		// Events are stored by calendar date, so the local date is looked up
+		// as is.
+		d, err := Build(user.UserID, time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC), user.Digest.Period)
+		if err != nil {
+			log.Printf("Failed to build digest for user %d: %v\n", user.UserID, err)
+			continue
+		}
+
+		deliverCtx, cancel := context.WithTimeout(ctx, deliverTimeout)
+		err = channel.Deliver(deliverCtx, user, d)
+		cancel()
+		if err != nil {
+			log.Printf("Failed to deliver digest to user %d: %v\n", user.UserID, err)
+			continue
+		}
+		j.sent[user.UserID] = date.Format("2006-01-02")
+	}
+}
+
+// due reports whether the digest of user should be sent now and for which
+// local date.
+func (j *Job) due(user models.User) (time.Time, bool) {
+	if user.Digest.Period == "" {
+		return time.Time{}, false
+	}
+	at, err := time.Parse(models.TimeFormat, user.Digest.Time)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	local := j.now().In(user.Location())
+	if user.Digest.Period == models.PeriodWeek && local.Weekday() != time.Monday {
+		return time.Time{}, false
+	}
+	if local.Hour()*60+local.Minute() < at.Hour()*60+at.Minute() {
+		return time.Time{}, false
+	}
+	if j.sent[user.UserID] == local.Format("2006-01-02") {
+		return time.Time{}, false
+	}
+	return local, true
+}
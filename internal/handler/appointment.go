@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/service"
+	"net/http"
+)
+
+func CreateAppointmentTypeHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := ownUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	appointment, err := service.CreateAppointmentType(uid, r.FormValue("title"), r.FormValue("description"), r.FormValue("duration"),
+		service.WithAppointmentHours(r.FormValue("hours"), r.FormValue("time_zone")),
+		service.WithAppointmentCalendar(r.FormValue("calendar_id")),
+		service.WithAppointmentBuffer(r.FormValue("buffer")),
+		service.WithMinimumNotice(r.FormValue("minimum_notice")),
+		service.WithDailyLimit(r.FormValue("daily_limit")),
+	)
+	if errors.Is(err, models.ErrTitleIsRequired) {
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, appointment)
+}
+
+func DeleteAppointmentTypeHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := ownUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	err := service.DeleteAppointmentType(uid, r.FormValue("appointment_type_id"))
+	if errors.Is(err, models.ErrAppointmentTypeNotFound) {
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func GetAppointmentTypesHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := ownUser(w, r, r.URL.Query().Get("user_id"))
+	if !ok {
+		return
+	}
+
+	appointments, err := service.GetAppointmentTypes(uid)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, appointments)
+}
+
+// GetBookingPageHandler serves the public booking page of an appointment
+// type. It does not require authentication.
+func GetBookingPageHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	page, err := service.GetBookingPage(query.Get("token"), query.Get("from"), query.Get("to"))
+	if errors.Is(err, models.ErrAppointmentTypeNotFound) {
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, page)
+}
+
+// BookAppointmentHandler books a slot of a booking page. It does not require
+// authentication.
+func BookAppointmentHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	event, err := service.BookAppointment(r.FormValue("token"), r.FormValue("start"),
+		r.FormValue("name"), r.FormValue("email"), r.FormValue("note"))
+	if errors.Is(err, models.ErrSlotUnavailable) {
+		sendError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, models.ErrAppointmentTypeNotFound) || errors.Is(err, models.ErrCalendarNotFound) {
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, event)
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"http-calendar/internal/blob"
+	"http-calendar/internal/models"
+	"http-calendar/internal/service"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// maxFieldLength bounds the text fields of an upload.
+	maxFieldLength = 1024
+	// maxFormOverhead is what an upload may carry besides the file: the text
+	// fields and the headers of the parts.
+	maxFormOverhead = 64 << 10
+)
+
+// UploadAttachmentHandler attaches the "file" part of a multipart form to an
+// event. The content is streamed to the store, so user_id and event_id have
+// to come in the URL or in parts before the file.
+func UploadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, blob.MaxSize()+maxFormOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fields := r.URL.Query()
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			sendError(w, models.ErrInvalidAttachment.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			sendAttachmentError(w, err)
+			return
+		}
+		if part.FormName() != "file" {
+			value, err := io.ReadAll(io.LimitReader(part, maxFieldLength+1))
+			if err != nil {
+				sendAttachmentError(w, err)
+				return
+			}
+			if len(value) > maxFieldLength {
+				sendError(w, fmt.Sprintf("field %q is too long", part.FormName()), http.StatusBadRequest)
+				return
+			}
+			fields.Set(part.FormName(), string(value))
+			continue
+		}
+
+		uid, ok := actingUser(w, r, fields.Get("user_id"))
+		if !ok {
+			return
+		}
+		attachment, err := service.AddAttachment(uid, fields.Get("event_id"), part.FileName(), part, actorOptions(r, uid)...)
+		if err != nil {
+			sendAttachmentError(w, err)
+			return
+		}
+		sendResult(w, attachment)
+		return
+	}
+}
+
+func DeleteAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := actingUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	err := service.DeleteAttachment(uid, r.FormValue("event_id"), r.FormValue("attachment_id"), actorOptions(r, uid)...)
+	if err != nil {
+		sendAttachmentError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetAttachmentHandler streams the content of an attachment. It supports
+// range and conditional requests.
+func GetAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	uid, ok := actingUser(w, r, query.Get("user_id"))
+	if !ok {
+		return
+	}
+
+	attachment, file, err := service.OpenAttachment(uid, query.Get("event_id"), query.Get("attachment_id"), queryOptions(r, uid)...)
+	if err != nil {
+		sendAttachmentError(w, err)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", strconv.Quote(attachment.Hash))
+	http.ServeContent(w, r, attachment.Name, attachment.UploadedAt, file)
+}
+
+func sendAttachmentError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, models.ErrForbidden):
+		sendError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, models.ErrAttachmentTooLarge) || errors.As(err, &tooLarge):
+		sendError(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, models.ErrUserNotFound) || errors.Is(err, models.ErrEventNotFound) ||
+		errors.Is(err, models.ErrAttachmentNotFound) || errors.Is(err, models.ErrAttachmentsNotEnabled):
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		sendError(w, err.Error(), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"http-calendar/internal/auth"
+	"http-calendar/internal/models"
+	"http-calendar/internal/service"
+	"net/http"
+	"strconv"
+)
+
+// actingUser returns the user whose calendar the request works on. With
+// authentication it defaults to the principal; another user may be named and
+// the service then checks the calendar shares of that user, see actor.
+// Without authentication userID is trusted as is.
+func actingUser(w http.ResponseWriter, r *http.Request, userID string) (string, bool) {
+	return resolveUser(w, r, userID, true)
+}
+
+// ownUser is like actingUser but ignores shares and grants, for settings that
+// only the user may change.
+func ownUser(w http.ResponseWriter, r *http.Request, userID string) (string, bool) {
+	return resolveUser(w, r, userID, false)
+}
+
+func resolveUser(w http.ResponseWriter, r *http.Request, userID string, allowOthers bool) (string, bool) {
+	p, ok := auth.FromContext(r.Context())
+	if !ok {
+		return userID, true
+	}
+	if userID == "" {
+		return strconv.FormatUint(p.UserID, 10), true
+	}
+
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return "", false
+	}
+	if uid != p.UserID && !allowOthers && !p.CanActFor(uid) {
+		sendError(w, models.ErrForbidden.Error(), http.StatusForbidden)
+		return "", false
+	}
+	return userID, true
+}
+
+// actor returns the principal of the request as the service.Actor on the
+// calendar of userID. It is false for unauthenticated requests and for
+// principals with a configured grant, which act as the owner.
+func actor(r *http.Request, userID string) (service.Actor, bool) {
+	p, ok := auth.FromContext(r.Context())
+	if !ok {
+		return 0, false
+	}
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil || p.CanActFor(uid) {
+		return 0, false
+	}
+	return service.Actor(p.UserID), true
+}
+
+func queryOptions(r *http.Request, userID string) []service.QueryOption {
+	if a, ok := actor(r, userID); ok {
+		return []service.QueryOption{a}
+	}
+	return nil
+}
+
+// actorFor checks each user of a read spanning several users as actor
+// does.
+func actorFor(r *http.Request) service.ActorFor {
+	return func(owner uint64) (service.Actor, bool) {
+		return actor(r, strconv.FormatUint(owner, 10))
+	}
+}
+
+func actorOptions(r *http.Request, userID string) []service.EventOption {
+	if a, ok := actor(r, userID); ok {
+		return []service.EventOption{a}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/service"
+	"net/http"
+)
+
+func UpdateAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := ownUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	availability, err := service.UpdateAvailability(uid,
+		service.WithWeeklyHours(r.FormValue("working_hours"), r.FormValue("time_zone")),
+		service.WithAutoDecline(r.FormValue("auto_decline")),
+	)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, availability)
+}
+
+func GetAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := ownUser(w, r, r.URL.Query().Get("user_id"))
+	if !ok {
+		return
+	}
+
+	availability, err := service.GetAvailability(uid)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, availability)
+}
+
+func AddOutOfOfficeHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := ownUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	ooo, err := service.AddOutOfOffice(uid, r.FormValue("from"), r.FormValue("to"), r.FormValue("note"))
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, ooo)
+}
+
+func DeleteOutOfOfficeHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := ownUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	err := service.DeleteOutOfOffice(uid, r.FormValue("out_of_office_id"))
+	if errors.Is(err, models.ErrOutOfOfficeNotFound) {
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
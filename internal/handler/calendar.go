@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/service"
+	"net/http"
+)
+
+func CreateCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := ownUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	calendar, err := service.CreateCalendar(uid, r.FormValue("name"), r.FormValue("color"), r.FormValue("time_zone"), r.FormValue("conflict_mode"))
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, calendar)
+}
+
+func UpdateCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := ownUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	calendar, err := service.UpdateCalendar(uid, r.FormValue("calendar_id"), r.FormValue("name"), r.FormValue("color"), r.FormValue("time_zone"), r.FormValue("conflict_mode"))
+	if errors.Is(err, models.ErrCalendarNotFound) {
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, calendar)
+}
+
+func DeleteCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := ownUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	err := service.DeleteCalendar(uid, r.FormValue("calendar_id"))
+	if errors.Is(err, models.ErrCalendarNotFound) || errors.Is(err, models.ErrCalendarNotEmpty) {
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func GetCalendarsHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := actingUser(w, r, r.URL.Query().Get("user_id"))
+	if !ok {
+		return
+	}
+
+	calendars, err := service.GetCalendars(uid, queryOptions(r, uid)...)
+	if errors.Is(err, models.ErrForbidden) {
+		sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, calendars)
+}
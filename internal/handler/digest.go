@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+	"http-calendar/internal/digest"
+	"http-calendar/internal/models"
+	"http-calendar/internal/service"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func GetDigestHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := actingUser(w, r, r.URL.Query().Get("user_id"))
+	if !ok {
+		return
+	}
+	uid, err := strconv.ParseUint(user, 10, 64)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	date, err := time.Parse(service.DateFormat, r.URL.Query().Get("date"))
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = models.PeriodDay
+	}
+
+	d, err := digest.Build(uid, date, period, queryOptions(r, user)...)
+	if errors.Is(err, models.ErrForbidden) {
+		sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, models.ErrInvalidDigest) {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, contentType, err := digest.Render(d, r.URL.Query().Get("format"))
+	if errors.Is(err, digest.ErrInvalidFormat) {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(data); err != nil {
+		log.Printf("Failed write response: %v\n", err)
+	}
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/service"
+	"net/http"
+)
+
+func DefineFieldHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := ownUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	field, err := service.DefineField(uid, r.FormValue("calendar_id"), r.FormValue("name"), r.FormValue("type"),
+		r.FormValue("values"), r.FormValue("required"))
+	if errors.Is(err, models.ErrCalendarNotFound) {
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, field)
+}
+
+func DeleteFieldHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := ownUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	err := service.DeleteField(uid, r.FormValue("calendar_id"), r.FormValue("name"))
+	if errors.Is(err, models.ErrFieldNotFound) || errors.Is(err, models.ErrCalendarNotFound) {
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func GetFieldsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	uid, ok := actingUser(w, r, query.Get("user_id"))
+	if !ok {
+		return
+	}
+
+	fields, err := service.GetFields(uid, query.Get("calendar_id"), queryOptions(r, uid)...)
+	if errors.Is(err, models.ErrForbidden) {
+		sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, fields)
+}
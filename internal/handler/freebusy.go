@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"http-calendar/internal/ical"
+	"http-calendar/internal/models"
+	"http-calendar/internal/service"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+func GetFreeBusyHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	userIDs := query.Get("user_ids")
+	if userIDs == "" {
+		sendError(w, "user_ids is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := service.GetFreeBusy(userIDs, query.Get("from"), query.Get("to"), actorFor(r))
+	if errors.Is(err, models.ErrForbidden) {
+		sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch query.Get("format") {
+	case "", "json":
+		sendResult(w, result)
+	case "ical":
+		data := ical.FreeBusy(*result)
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(http.StatusOK)
+		if _, err = w.Write(data); err != nil {
+			log.Printf("Failed write response: %v\n", err)
+		}
+	default:
+		sendError(w, "invalid format", http.StatusBadRequest)
+	}
+}
+
+func FindSlotsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	userIDs := query.Get("user_ids")
+	if userIDs == "" {
+		sendError(w, "user_ids is required", http.StatusBadRequest)
+		return
+	}
+
+	opts := []service.SlotOption{
+		service.WithWorkingHours(query.Get("work_start"), query.Get("work_end")),
+		service.InTimeZone(query.Get("time_zone")),
+		service.WithBuffer(query.Get("buffer")),
+		service.WithPreferredDays(query.Get("days")),
+		service.WithLimit(query.Get("limit")),
+		actorFor(r),
+	}
+
+	slots, err := service.FindSlots(userIDs, query.Get("duration"), query.Get("from"), query.Get("to"), opts...)
+	if errors.Is(err, models.ErrForbidden) {
+		sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, slots)
+}
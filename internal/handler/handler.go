@@ -6,25 +6,74 @@ import (
 	"http-calendar/internal/models"
 	"http-calendar/internal/service"
 	"log"
+	"maps"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 type SuccessResponse struct {
-	Result *models.Event `json:"result"`
+	Result    *models.Event  `json:"result"`
+	Conflicts []models.Event `json:"conflicts,omitempty"`
+}
+
+type ResultResponse struct {
+	Result any `json:"result"`
+}
+
+// EventsResponse lists the events of a view together with the tasks due in
+// it, when they were asked for with include_tasks.
+type EventsResponse struct {
+	Events []models.Event `json:"events"`
+	Tasks  []models.Task  `json:"tasks"`
 }
 
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error     string         `json:"error"`
+	Conflicts []models.Event `json:"conflicts,omitempty"`
 }
 
-func sendSuccess(w http.ResponseWriter, model *models.Event) {
+func sendSuccess(w http.ResponseWriter, model *models.Event, conflicts ...models.Event) {
 	w.WriteHeader(http.StatusOK)
-	err := json.NewEncoder(w).Encode(SuccessResponse{Result: model})
+	err := json.NewEncoder(w).Encode(SuccessResponse{Result: model, Conflicts: conflicts})
 	if err != nil {
 		log.Printf("Failed encode response: %v\n", err)
 	}
 }
 
+func sendResult(w http.ResponseWriter, result any) {
+	w.WriteHeader(http.StatusOK)
+	err := json.NewEncoder(w).Encode(ResultResponse{Result: result})
+	if err != nil {
+		log.Printf("Failed encode response: %v\n", err)
+	}
+}
+
+// sendWriteError answers failed creates and updates of events.
+func sendWriteError(w http.ResponseWriter, err error) {
+	var conflict *models.ConflictError
+	switch {
+	case errors.As(err, &conflict):
+		w.WriteHeader(http.StatusConflict)
+		err = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error(), Conflicts: conflict.Events})
+		if err != nil {
+			log.Printf("Failed encode response: %v\n", err)
+		}
+	case errors.Is(err, models.ErrResourceBusy) || errors.Is(err, models.ErrEventChanged):
+		sendError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, models.ErrTitleIsRequired):
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, models.ErrForbidden):
+		sendError(w, err.Error(), http.StatusForbidden)
+	case isInputError(err):
+		sendError(w, err.Error(), http.StatusBadRequest)
+	default:
+		sendError(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func sendError(w http.ResponseWriter, message string, statusCode int) {
 	w.WriteHeader(statusCode)
 	err := json.NewEncoder(w).Encode(ErrorResponse{Error: message})
@@ -38,22 +87,22 @@ func CreateHandler(w http.ResponseWriter, r *http.Request) {
 		sendError(w, err.Error(), http.StatusBadRequest)
 	}
 
-	uid := r.FormValue("user_id")
+	uid, ok := actingUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
 	date := r.FormValue("date")
 	title := r.FormValue("title")
 	description := r.FormValue("description")
 
-	model, err := service.CreateEvent(uid, date, title, description)
-	if errors.Is(err, models.ErrTitleIsRequired) {
-		sendError(w, err.Error(), http.StatusServiceUnavailable)
-		return
-	}
+	var conflicts []models.Event
+	model, err := service.CreateEvent(uid, date, title, description, eventOptions(r, uid, &conflicts)...)
 	if err != nil {
-		sendError(w, err.Error(), http.StatusInternalServerError)
+		sendWriteError(w, err)
 		return
 	}
 
-	sendSuccess(w, model)
+	sendSuccess(w, model, conflicts...)
 }
 
 func UpdateHandler(w http.ResponseWriter, r *http.Request) {
@@ -61,23 +110,69 @@ func UpdateHandler(w http.ResponseWriter, r *http.Request) {
 		sendError(w, err.Error(), http.StatusBadRequest)
 	}
 
-	uid := r.FormValue("user_id")
+	uid, ok := actingUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
 	eid := r.FormValue("event_id")
 	date := r.FormValue("date")
 	title := r.FormValue("title")
 	description := r.FormValue("description")
 
-	model, err := service.UpdateEvent(uid, eid, date, title, description)
-	if errors.Is(err, models.ErrTitleIsRequired) {
-		sendError(w, err.Error(), http.StatusServiceUnavailable)
-		return
-	}
+	var conflicts []models.Event
+	model, err := service.UpdateEvent(uid, eid, date, title, description, eventOptions(r, uid, &conflicts)...)
 	if err != nil {
-		sendError(w, err.Error(), http.StatusInternalServerError)
+		sendWriteError(w, err)
 		return
 	}
 
-	sendSuccess(w, model)
+	sendSuccess(w, model, conflicts...)
+}
+
+func eventOptions(r *http.Request, userID string, conflicts *[]models.Event) []service.EventOption {
+	return append([]service.EventOption{
+		service.WithTime(r.FormValue("time")),
+		service.WithDuration(r.FormValue("duration")),
+		service.WithAlarms(r.FormValue("alarms")),
+		service.InCalendar(r.FormValue("calendar_id")),
+		service.WithAttendees(r.FormValue("attendees")),
+		service.WithConflictMode(r.FormValue("conflict_mode"), conflicts),
+		service.WithResources(r.FormValue("resources")),
+		service.WithTags(r.FormValue("tags")),
+		service.WithCategory(r.FormValue("category")),
+		service.WithStatus(r.FormValue("status")),
+		service.WithVisibility(r.FormValue("visibility")),
+		service.WithTransparency(r.FormValue("transparency")),
+		service.WithLocation(r.FormValue("location"), r.FormValue("lat"), r.FormValue("lon"), r.FormValue("video_url")),
+	}, append(fieldOptions(r.Form), actorOptions(r, userID)...)...)
+}
+
+// fieldPrefix starts the names of parameters that carry custom fields, as
+// in "field.ticket=OPS-12".
+const fieldPrefix = "field."
+
+// fieldOptions sets the custom fields given in form.
+func fieldOptions(form url.Values) []service.EventOption {
+	var opts []service.EventOption
+	for _, key := range slices.Sorted(maps.Keys(form)) {
+		if name, ok := strings.CutPrefix(key, fieldPrefix); ok {
+			opts = append(opts, service.WithField(name, form.Get(key)))
+		}
+	}
+	return opts
+}
+
+func isInputError(err error) bool {
+	return errors.Is(err, models.ErrInvalidTime) || errors.Is(err, models.ErrInvalidAlarm) ||
+		errors.Is(err, models.ErrInvalidDuration) ||
+		errors.Is(err, models.ErrCalendarNotFound) || errors.Is(err, models.ErrInvalidAttendee) ||
+		errors.Is(err, models.ErrInvalidConflictMode) || errors.Is(err, models.ErrResourceNotFound) ||
+		errors.Is(err, models.ErrCapacityExceeded) ||
+		errors.Is(err, models.ErrInvalidTag) || errors.Is(err, models.ErrInvalidCategory) ||
+		errors.Is(err, models.ErrInvalidEventStatus) || errors.Is(err, models.ErrInvalidVisibility) ||
+		errors.Is(err, models.ErrInvalidTransparency) || errors.Is(err, models.ErrInvalidLocation) ||
+		errors.Is(err, models.ErrFieldNotFound) || errors.Is(err, models.ErrInvalidFieldValue) ||
+		errors.Is(err, models.ErrFieldIsRequired)
 }
 
 func DeleteHandler(w http.ResponseWriter, r *http.Request) {
@@ -86,10 +181,17 @@ func DeleteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	uid := r.FormValue("user_id")
+	uid, ok := actingUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
 	eid := r.FormValue("event_id")
 
-	err := service.DeleteEvent(uid, eid)
+	err := service.DeleteEvent(uid, eid, actorOptions(r, uid)...)
+	if errors.Is(err, models.ErrForbidden) {
+		sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
 	if err != nil && (errors.Is(err, models.ErrUserNotFound) || errors.Is(err, models.ErrEventNotFound)) {
 		sendError(w, err.Error(), http.StatusServiceUnavailable)
 		return
@@ -101,23 +203,108 @@ func DeleteHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func RestoreHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := actingUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	event, err := service.RestoreEvent(uid, r.FormValue("event_id"), actorOptions(r, uid)...)
+	if errors.Is(err, models.ErrNotInTrash) {
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		sendWriteError(w, err)
+		return
+	}
+	sendResult(w, event)
+}
+
+func GetTrashHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := actingUser(w, r, r.URL.Query().Get("user_id"))
+	if !ok {
+		return
+	}
+
+	trash, err := service.GetTrash(uid, queryOptions(r, uid)...)
+	if errors.Is(err, models.ErrForbidden) {
+		sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, trash)
+}
+
 func GetEventsForDayHandler(w http.ResponseWriter, r *http.Request) {
-	getEvents(w, r, service.GetEventsForDay)
+	getEvents(w, r, byDate(r, service.GetEventsForDay))
 }
 
 func GetEventsForWeekHandler(w http.ResponseWriter, r *http.Request) {
-	getEvents(w, r, service.GetEventsForWeek)
+	getEvents(w, r, byDate(r, service.GetEventsForWeek))
 }
 
 func GetEventsForMonthHandler(w http.ResponseWriter, r *http.Request) {
-	getEvents(w, r, service.GetEventsForMonth)
+	getEvents(w, r, byDate(r, service.GetEventsForMonth))
+}
+
+func GetEventsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	getEvents(w, r, func(userID string, opts ...service.QueryOption) ([]models.Event, error) {
+		return service.GetEventsBetween(userID, query.Get("from"), query.Get("to"), opts...)
+	})
+}
+
+type eventsFunc func(userID string, opts ...service.QueryOption) ([]models.Event, error)
+
+func byDate(r *http.Request, fn func(userID, date string, opts ...service.QueryOption) ([]models.Event, error)) eventsFunc {
+	return func(userID string, opts ...service.QueryOption) ([]models.Event, error) {
+		return fn(userID, r.URL.Query().Get("date"), opts...)
+	}
 }
 
-func getEvents(w http.ResponseWriter, r *http.Request, fn func(userID, date string) ([]models.Event, error)) {
-	uid := r.URL.Query().Get("user_id")
-	date := r.URL.Query().Get("date")
+// eventFilters returns the filters of a read of events named in the query
+// string.
+func eventFilters(query url.Values) []service.QueryOption {
+	opts := []service.QueryOption{
+		service.InCalendars(query.Get("calendar_id")),
+		service.TaggedWith(query.Get("tag"), query.Get("tag_mode")),
+		service.InCategory(query.Get("category")),
+		service.TitleContains(query.Get("title")),
+	}
+	for _, key := range slices.Sorted(maps.Keys(query)) {
+		if name, ok := strings.CutPrefix(key, fieldPrefix); ok {
+			opts = append(opts, service.WithFieldValue(name, query.Get(key)))
+		}
+	}
+	return opts
+}
+
+func getEvents(w http.ResponseWriter, r *http.Request, fn eventsFunc) {
+	uid, ok := actingUser(w, r, r.URL.Query().Get("user_id"))
+	if !ok {
+		return
+	}
 
-	events, err := fn(uid, date)
+	opts := append(queryOptions(r, uid), eventFilters(r.URL.Query())...)
+	var tasks []models.Task
+	withTasks, _ := strconv.ParseBool(r.URL.Query().Get("include_tasks"))
+	if withTasks {
+		opts = append(opts, service.IncludeTasks(&tasks))
+	}
+	events, err := fn(uid, opts...)
+	if errors.Is(err, models.ErrForbidden) {
+		sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
 	if errors.Is(err, models.ErrUserNotFound) {
 		sendError(w, err.Error(), http.StatusServiceUnavailable)
 		return
@@ -128,7 +315,11 @@ func getEvents(w http.ResponseWriter, r *http.Request, fn func(userID, date stri
 	}
 
 	w.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(w).Encode(events)
+	if withTasks {
+		err = json.NewEncoder(w).Encode(EventsResponse{Events: events, Tasks: tasks})
+	} else {
+		err = json.NewEncoder(w).Encode(events)
+	}
 	if err != nil {
 		log.Printf("Failed encode response: %v\n", err)
 	}
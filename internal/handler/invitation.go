@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/service"
+	"net/http"
+)
+
+func SendInvitationsHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := actingUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	invited, err := service.SendInvitations(r.Context(), uid, r.FormValue("event_id"), actorOptions(r, uid)...)
+	if errors.Is(err, models.ErrForbidden) {
+		sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, models.ErrUserNotFound) || errors.Is(err, models.ErrEventNotFound) || errors.Is(err, models.ErrMailDisabled) {
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sendResult(w, invited)
+}
+
+func RSVPHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := ownUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	event, err := service.RSVP(uid, r.FormValue("organizer_id"), r.FormValue("event_id"), r.FormValue("status"))
+	if errors.Is(err, models.ErrUserNotFound) || errors.Is(err, models.ErrEventNotFound) || errors.Is(err, models.ErrNotInvited) {
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if errors.Is(err, models.ErrEventChanged) {
+		sendError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendSuccess(w, event)
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/service"
+	"net/http"
+)
+
+func GetEventsNearHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	uid, ok := actingUser(w, r, query.Get("user_id"))
+	if !ok {
+		return
+	}
+
+	opts := append(queryOptions(r, uid), eventFilters(query)...)
+	events, err := service.GetEventsNear(uid, query.Get("lat"), query.Get("lon"), query.Get("radius"),
+		query.Get("from"), query.Get("to"), opts...)
+	if errors.Is(err, models.ErrForbidden) {
+		sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, events)
+}
+
+func GetEventsInAreaHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	uid, ok := actingUser(w, r, query.Get("user_id"))
+	if !ok {
+		return
+	}
+
+	opts := append(queryOptions(r, uid), eventFilters(query)...)
+	events, err := service.GetEventsInArea(uid, query.Get("bbox"), query.Get("from"), query.Get("to"), opts...)
+	if errors.Is(err, models.ErrForbidden) {
+		sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, events)
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/service"
+	"net/http"
+	"strconv"
+)
+
+func CreateResourceHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := ownUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	resource, err := service.CreateResource(uid, r.FormValue("name"), r.FormValue("kind"),
+		r.FormValue("capacity"), r.FormValue("quantity"), r.FormValue("approval_required"))
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, resource)
+}
+
+func DeleteResourceHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := ownUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	err := service.DeleteResource(uid, r.FormValue("resource_id"))
+	if errors.Is(err, models.ErrForbidden) {
+		sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, models.ErrResourceInUse) {
+		sendError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, models.ErrResourceNotFound) {
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func GetResourcesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("from") == "" && query.Get("to") == "" {
+		sendResult(w, service.GetResources())
+		return
+	}
+
+	resources, err := service.FindResources(query.Get("from"), query.Get("to"), query.Get("kind"), query.Get("capacity"))
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, resources)
+}
+
+func GetResourceAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	availability, err := service.GetResourceAvailability(query.Get("resource_id"), query.Get("from"), query.Get("to"))
+	if errors.Is(err, models.ErrResourceNotFound) {
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, availability)
+}
+
+func ReviewBookingHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := ownUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+	approve, err := strconv.ParseBool(r.FormValue("approve"))
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	booking, err := service.ReviewBooking(uid, r.FormValue("resource_id"), r.FormValue("organizer_id"), r.FormValue("event_id"), approve)
+	if errors.Is(err, models.ErrForbidden) {
+		sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, models.ErrResourceBusy) {
+		sendError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, models.ErrResourceNotFound) || errors.Is(err, models.ErrEventNotFound) || errors.Is(err, models.ErrUserNotFound) {
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, booking)
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/service"
+	"net/http"
+)
+
+func SearchHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	uid, ok := actingUser(w, r, query.Get("user_id"))
+	if !ok {
+		return
+	}
+
+	opts := append(queryOptions(r, uid), eventFilters(query)...)
+	results, err := service.SearchEvents(uid, query.Get("q"), query.Get("from"), query.Get("to"), query.Get("limit"), opts...)
+	if errors.Is(err, models.ErrForbidden) {
+		sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, results)
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"http-calendar/internal/models"
+	"http-calendar/internal/pubsub"
+	"http-calendar/internal/service"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const heartbeatInterval = 15 * time.Second
+
+func StreamHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := actingUser(w, r, r.URL.Query().Get("user_id"))
+	if !ok {
+		return
+	}
+	uid, err := strconv.ParseUint(user, 10, 64)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// visible hides the details of private events from other users. It is
+	// false once the share of the calendar was revoked.
+	visible := func(change models.Change) (models.Change, bool) { return change, true }
+	if a, ok := actor(r, user); ok {
+		// Changes carry the full event, so free/busy access is not enough.
+		if err = service.Authorize(uint64(a), uid, models.AccessRead); err != nil {
+			sendError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		visible = func(change models.Change) (models.Change, bool) {
+			change.Event, ok = service.VisibleEvent(uint64(a), uid, change.Event)
+			return change, ok
+		}
+	}
+
+	var lastID uint64
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		lastID, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			sendError(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	backlog, complete, changes, cancel := pubsub.Subscribe(uid, lastID)
+	defer cancel()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if !complete {
+		// The client missed changes that are no longer in the log and has to
+		// reload its view before applying new ones.
+		if _, err = fmt.Fprint(w, "event: reset\ndata: {}\n\n"); err != nil {
+			return
+		}
+	}
+	for _, change := range backlog {
+		change, ok := visible(change)
+		if !ok {
+			return
+		}
+		if err = writeChange(w, change); err != nil {
+			return
+		}
+	}
+	if err = rc.Flush(); err != nil {
+		log.Printf("Failed flush stream: %v\n", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case change, ok := <-changes:
+			if !ok {
+				return
+			}
+			if change, ok = visible(change); !ok {
+				return
+			}
+			err = writeChange(w, change)
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": ping\n\n")
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func writeChange(w http.ResponseWriter, change models.Change) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.ID, change.Type, data)
+	return err
+}
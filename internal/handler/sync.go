@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/service"
+	"net/http"
+)
+
+func SyncHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := actingUser(w, r, r.URL.Query().Get("user_id"))
+	if !ok {
+		return
+	}
+	token := r.URL.Query().Get("sync_token")
+
+	result, err := service.Sync(uid, token, queryOptions(r, uid)...)
+	if errors.Is(err, models.ErrForbidden) {
+		sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, models.ErrInvalidSyncToken) {
+		sendError(w, err.Error(), http.StatusGone)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, result)
+}
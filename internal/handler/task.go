@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/service"
+	"net/http"
+	"strconv"
+)
+
+func CreateTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := actingUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	task, err := service.CreateTask(uid, r.FormValue("title"), r.FormValue("description"),
+		r.FormValue("due"), r.FormValue("priority"), r.FormValue("calendar_id"), actorOptions(r, uid)...)
+	sendTask(w, task, err)
+}
+
+func CompleteTaskHandler(w http.ResponseWriter, r *http.Request) {
+	setTaskCompleted(w, r, service.CompleteTask)
+}
+
+func ReopenTaskHandler(w http.ResponseWriter, r *http.Request) {
+	setTaskCompleted(w, r, service.ReopenTask)
+}
+
+func setTaskCompleted(w http.ResponseWriter, r *http.Request, fn func(userID, taskID string, opts ...service.EventOption) (*models.Task, error)) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := actingUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	task, err := fn(uid, r.FormValue("task_id"), actorOptions(r, uid)...)
+	sendTask(w, task, err)
+}
+
+func DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := actingUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	err := service.DeleteTask(uid, r.FormValue("task_id"), actorOptions(r, uid)...)
+	if err != nil {
+		sendTask(w, nil, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func GetTasksHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	uid, ok := actingUser(w, r, query.Get("user_id"))
+	if !ok {
+		return
+	}
+
+	all, _ := strconv.ParseBool(query.Get("all"))
+	opts := append(queryOptions(r, uid), service.InCalendars(query.Get("calendar_id")))
+	tasks, err := service.GetTasks(uid, all, opts...)
+	if errors.Is(err, models.ErrForbidden) {
+		sendError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, tasks)
+}
+
+func sendTask(w http.ResponseWriter, task *models.Task, err error) {
+	switch {
+	case err == nil:
+		sendResult(w, task)
+	case errors.Is(err, models.ErrForbidden):
+		sendError(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, models.ErrTitleIsRequired), errors.Is(err, models.ErrTaskNotFound):
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		sendError(w, err.Error(), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/service"
+	"net/http"
+)
+
+func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uid, ok := ownUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+
+	user, err := service.UpdateUser(uid,
+		service.WithEmail(r.FormValue("email")),
+		service.WithTimeZone(r.FormValue("time_zone")),
+		service.WithDigest(r.FormValue("digest_period"), r.FormValue("digest_time"), r.FormValue("digest_channel"), r.FormValue("digest_target")),
+	)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, user)
+}
+
+func GetUserHandler(w http.ResponseWriter, r *http.Request) {
+	uid, ok := ownUser(w, r, r.URL.Query().Get("user_id"))
+	if !ok {
+		return
+	}
+
+	user, err := service.GetUser(uid)
+	if errors.Is(err, models.ErrUserNotFound) {
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, user)
+}
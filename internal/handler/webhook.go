@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/webhook"
+	"net/http"
+	"strconv"
+)
+
+func CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, ok := ownUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+	uid, err := strconv.ParseUint(user, 10, 64)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hook, err := webhook.Create(uid, r.FormValue("url"), r.FormValue("secret"))
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, hook)
+}
+
+func DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, ok := ownUser(w, r, r.FormValue("user_id"))
+	if !ok {
+		return
+	}
+	uid, err := strconv.ParseUint(user, 10, 64)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	wid, err := strconv.ParseUint(r.FormValue("webhook_id"), 10, 64)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = webhook.Delete(uid, wid)
+	if errors.Is(err, models.ErrWebhookNotFound) {
+		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func GetWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownUser(w, r, r.URL.Query().Get("user_id"))
+	if !ok {
+		return
+	}
+	uid, err := strconv.ParseUint(user, 10, 64)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendResult(w, webhook.List(uid))
+}
+
+func GetWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownUser(w, r, r.URL.Query().Get("user_id"))
+	if !ok {
+		return
+	}
+	uid, err := strconv.ParseUint(user, 10, 64)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("status") == models.DeliveryDead {
+		sendResult(w, webhook.DeadLetters(uid))
+		return
+	}
+	sendResult(w, webhook.Deliveries(uid))
+}
@@ -0,0 +1,190 @@
+package ical
+
+import (
+	"fmt"
+	"http-calendar/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MethodPublish = "PUBLISH"
+	MethodRequest = "REQUEST"
+	MethodReply   = "REPLY"
+
+	dateFormat     = "20060102"
+	dateTimeFormat = "20060102T150405Z"
+	maxLineLength  = 75
+)
+
+type writer struct {
+	b strings.Builder
+}
+
+// line writes a content line folded at 75 octets as required by RFC 5545.
+func (w *writer) line(name, value string) {
+	l := name + ":" + value
+	for len(l) > maxLineLength {
+		cut := maxLineLength
+		for cut > 0 && !isRuneStart(l[cut]) {
+			cut--
+		}
+		w.b.WriteString(l[:cut])
+		w.b.WriteString("\r\n ")
+		l = l[cut:]
+	}
+	w.b.WriteString(l)
+	w.b.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+func begin(method string) *writer {
+	w := &writer{}
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//http-calendar//EN")
+	w.line("CALSCALE", "GREGORIAN")
+	if method != "" {
+		w.line("METHOD", method)
+	}
+	return w
+}
+
+func (w *writer) end() []byte {
+	w.line("END", "VCALENDAR")
+	return []byte(w.b.String())
+}
+
+// UID returns the globally unique identifier of the event.
+func UID(event models.Event) string {
+	return fmt.Sprintf("%d-%d@http-calendar", event.UserID, event.EventID)
+}
+
+// Events renders the events as an iCalendar object.
+func Events(method string, events ...models.Event) []byte {
+	w := begin(method)
+	stamp := time.Now().UTC().Format(dateTimeFormat)
+	for _, event := range events {
+		w.event(stamp, "", event)
+	}
+	return w.end()
+}
+
+// Scheduling renders an invitation or a reply for event organized by the
+// owner of the address organizer. Only attendees with an email address are
+// listed.
+func Scheduling(method, organizer string, event models.Event) []byte {
+	w := begin(method)
+	w.event(time.Now().UTC().Format(dateTimeFormat), organizer, event)
+	return w.end()
+}
+
+func (w *writer) event(stamp, organizer string, event models.Event) {
+	w.line("BEGIN", "VEVENT")
+	w.line("UID", UID(event))
+	w.line("DTSTAMP", stamp)
+	if event.Time == "" {
+		w.line("DTSTART;VALUE=DATE", event.Date.Format(dateFormat))
+		w.line("DTEND;VALUE=DATE", event.Date.AddDate(0, 0, 1).Format(dateFormat))
+	} else {
+		w.line("DTSTART", event.Start().UTC().Format(dateTimeFormat))
+	}
+	w.line("SUMMARY", escape(event.Title))
+	if event.Description != "" {
+		w.line("DESCRIPTION", escape(event.Description))
+	}
+	if event.Status != "" {
+		w.line("STATUS", strings.ToUpper(event.Status))
+	}
+	if event.Visibility != "" {
+		w.line("CLASS", strings.ToUpper(event.Visibility))
+	}
+	if event.Transparency != "" {
+		w.line("TRANSP", strings.ToUpper(event.Transparency))
+	}
+	if event.Location != nil {
+		w.location(*event.Location)
+	}
+	if categories := categories(event); len(categories) > 0 {
+		w.line("CATEGORIES", strings.Join(categories, ","))
+	}
+	if organizer != "" {
+		w.line("ORGANIZER", "mailto:"+organizer)
+	}
+	for _, attendee := range event.Attendees {
+		if attendee.Email == "" {
+			continue
+		}
+		w.line("ATTENDEE;PARTSTAT="+strings.ToUpper(attendee.Status), "mailto:"+attendee.Email)
+	}
+	for _, alarm := range event.Alarms {
+		w.line("BEGIN", "VALARM")
+		w.line("ACTION", "DISPLAY")
+		w.line("DESCRIPTION", escape(event.Title))
+		w.line("TRIGGER", fmt.Sprintf("-PT%dM", alarm.MinutesBefore))
+		w.line("END", "VALARM")
+	}
+	w.line("END", "VEVENT")
+}
+
+// location writes the address of location, or its video link when it
+// has none, and its coordinates.
+func (w *writer) location(location models.Location) {
+	if location.Address != "" {
+		w.line("LOCATION", escape(location.Address))
+	} else if location.VideoURL != "" {
+		w.line("LOCATION", escape(location.VideoURL))
+	}
+	if p, ok := location.Point(); ok {
+		w.line("GEO", strconv.FormatFloat(p.Latitude, 'f', -1, 64)+";"+strconv.FormatFloat(p.Longitude, 'f', -1, 64))
+	}
+	if location.VideoURL != "" {
+		w.line("URL", location.VideoURL)
+	}
+}
+
+// categories returns the escaped category and tags of event.
+func categories(event models.Event) []string {
+	var result []string
+	if event.Category != "" {
+		result = append(result, escape(event.Category))
+	}
+	for _, tag := range event.Tags {
+		result = append(result, escape(tag))
+	}
+	return result
+}
+
+// FreeBusy renders the busy time of the users in result as one VFREEBUSY
+// component per user.
+func FreeBusy(result models.FreeBusyResult) []byte {
+	w := begin(MethodPublish)
+	stamp := time.Now().UTC().Format(dateTimeFormat)
+	for _, user := range result.Users {
+		w.line("BEGIN", "VFREEBUSY")
+		w.line("UID", fmt.Sprintf("%d-freebusy@http-calendar", user.UserID))
+		w.line("DTSTAMP", stamp)
+		w.line("DTSTART", result.From.UTC().Format(dateTimeFormat))
+		w.line("DTEND", result.To.UTC().Format(dateTimeFormat))
+		for _, busy := range user.Busy {
+			w.line("FREEBUSY;FBTYPE=BUSY", period(busy))
+		}
+		for _, unavailable := range user.Unavailable {
+			w.line("FREEBUSY;FBTYPE=BUSY-UNAVAILABLE", period(unavailable))
+		}
+		w.line("END", "VFREEBUSY")
+	}
+	return w.end()
+}
+
+func period(interval models.Interval) string {
+	return interval.Start.UTC().Format(dateTimeFormat) + "/" + interval.End.UTC().Format(dateTimeFormat)
+}
@@ -0,0 +1,111 @@
+package ical
+
+import (
+	"http-calendar/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvents(t *testing.T) {
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	lat, lon := 52.52, 13.405
+	data := string(Events(MethodRequest,
+		models.Event{UserID: 1, EventID: 2, Date: date, Title: "All day; off-site", Alarms: []models.Alarm{{MinutesBefore: 15}}},
+		models.Event{UserID: 1, EventID: 3, Date: date, Time: "10:30", Title: "Standup", Category: "Meeting", Tags: []string{"team", "daily"},
+			Status: models.EventTentative, Visibility: models.VisibilityPrivate, Transparency: models.TransparencyTransparent,
+			Location: &models.Location{Address: "Room 4, Main St", Latitude: &lat, Longitude: &lon, VideoURL: "https://meet.example.com/abc"}},
+	))
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"METHOD:REQUEST\r\n",
+		"UID:1-2@http-calendar\r\n",
+		"DTSTART;VALUE=DATE:20240115\r\n",
+		"DTEND;VALUE=DATE:20240116\r\n",
+		"SUMMARY:All day\\; off-site\r\n",
+		"TRIGGER:-PT15M\r\n",
+		"DTSTART:20240115T103000Z\r\n",
+		"CATEGORIES:Meeting,team,daily\r\n",
+		"STATUS:TENTATIVE\r\n",
+		"CLASS:PRIVATE\r\n",
+		"TRANSP:TRANSPARENT\r\n",
+		"LOCATION:Room 4\\, Main St\r\n",
+		"GEO:52.52;13.405\r\n",
+		"URL:https://meet.example.com/abc\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(data, want) {
+			t.Errorf("Expected %q in\n%s", want, data)
+		}
+	}
+}
+
+func TestLineFolding(t *testing.T) {
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	data := string(Events("", models.Event{Date: date, Title: strings.Repeat("ж", 100)}))
+
+	for _, line := range strings.Split(data, "\r\n") {
+		if len(line) > maxLineLength {
+			t.Errorf("Line longer than %d octets: %q", maxLineLength, line)
+		}
+	}
+	unfolded := strings.ReplaceAll(data, "\r\n ", "")
+	if !strings.Contains(unfolded, "SUMMARY:"+strings.Repeat("ж", 100)) {
+		t.Errorf("Folded summary does not unfold to the title")
+	}
+}
+
+func TestScheduling(t *testing.T) {
+	event := models.Event{
+		UserID:  1,
+		EventID: 2,
+		Date:    time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		Time:    "10:00",
+		Title:   "Planning",
+		Attendees: []models.Attendee{
+			{UserID: 3, Email: "carol@example.com", Status: models.StatusAccepted},
+			{UserID: 4, Status: models.StatusNeedsAction},
+			{Email: "dave@example.com", Status: models.StatusNeedsAction},
+		},
+	}
+
+	got := string(Scheduling(MethodRequest, "alice@example.com", event))
+	for _, want := range []string{
+		"METHOD:REQUEST\r\n",
+		"ORGANIZER:mailto:alice@example.com\r\n",
+		"ATTENDEE;PARTSTAT=ACCEPTED:mailto:carol@example.com\r\n",
+		"ATTENDEE;PARTSTAT=NEEDS-ACTION:mailto:dave@example.com\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Scheduling() is missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "ATTENDEE") != 2 {
+		t.Errorf("Attendees without address should be skipped:\n%s", got)
+	}
+}
+
+func TestFreeBusy(t *testing.T) {
+	from := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	got := string(FreeBusy(models.FreeBusyResult{
+		From: from,
+		To:   from.AddDate(0, 0, 1),
+		Users: []models.FreeBusy{{
+			UserID: 1,
+			Busy:   []models.Interval{{Start: from.Add(9 * time.Hour), End: from.Add(10 * time.Hour)}},
+		}},
+	}))
+
+	for _, want := range []string{
+		"BEGIN:VFREEBUSY\r\n",
+		"UID:1-freebusy@http-calendar\r\n",
+		"DTSTART:20240115T000000Z\r\n",
+		"DTEND:20240116T000000Z\r\n",
+		"FREEBUSY;FBTYPE=BUSY:20240115T090000Z/20240115T100000Z\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FreeBusy() is missing %q in:\n%s", want, got)
+		}
+	}
+}
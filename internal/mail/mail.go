@@ -0,0 +1,67 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"time"
+)
+
+const (
+	defaultAttempts = 3
+	defaultBackoff  = 2 * time.Second
+)
+
+// Sender delivers messages through an SMTP relay, retrying transient
+// failures with exponential backoff.
+type Sender struct {
+	Addr     string
+	From     string
+	Auth     smtp.Auth
+	Attempts int
+	Backoff  time.Duration
+}
+
+func NewSender(host, port, username, password, from string) *Sender {
+	s := &Sender{
+		Addr:     net.JoinHostPort(host, port),
+		From:     from,
+		Attempts: defaultAttempts,
+		Backoff:  defaultBackoff,
+	}
+	if username != "" {
+		s.Auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s
+}
+
+func (s *Sender) Send(ctx context.Context, msg *Message) error {
+	data, err := msg.Bytes(s.From)
+	if err != nil {
+		return err
+	}
+
+	delay := s.Backoff
+	for attempt := 1; ; attempt++ {
+		err = smtp.SendMail(s.Addr, s.Auth, s.From, msg.To, data)
+		if err == nil || attempt >= s.Attempts || permanent(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// permanent reports whether the relay rejected the message with a 5xx reply
+// that will not succeed on retry.
+func permanent(err error) bool {
+	var protoErr *textproto.Error
+	return errors.As(err, &protoErr) && protoErr.Code >= 500
+}
@@ -0,0 +1,269 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"http-calendar/internal/models"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	netmail "net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTP is a minimal in-process SMTP server. The first failures
+// connections are answered with reply instead of a greeting.
+type fakeSMTP struct {
+	ln       net.Listener
+	failures int
+	reply    string
+
+	mu       sync.Mutex
+	conns    int
+	messages []string
+}
+
+func startFakeSMTP(t *testing.T, failures int, reply string) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	s := &fakeSMTP{ln: ln, failures: failures, reply: reply}
+	go s.serve()
+	t.Cleanup(func() { _ = ln.Close() })
+	return s
+}
+
+func (s *fakeSMTP) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTP) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	s.mu.Lock()
+	s.conns++
+	fail := s.conns <= s.failures
+	s.mu.Unlock()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = fmt.Fprintf(conn, "%s\r\n", line) }
+	if fail {
+		reply(s.reply)
+		return
+	}
+	reply("220 localhost ESMTP")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"), strings.HasPrefix(cmd, "RSET"):
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func (s *fakeSMTP) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+func newTestSender(s *fakeSMTP) *Sender {
+	host, port, _ := net.SplitHostPort(s.ln.Addr().String())
+	sender := NewSender(host, port, "", "", "calendar@example.com")
+	sender.Backoff = time.Millisecond
+	return sender
+}
+
+var testEvent = models.Event{
+	UserID:      1,
+	EventID:     2,
+	Date:        time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+	Time:        "10:00",
+	Title:       "Planning <Q1>",
+	Description: "Bring numbers",
+}
+
+func TestSendInvitation(t *testing.T) {
+	server := startFakeSMTP(t, 0, "")
+	sender := newTestSender(server)
+
+	msg, err := InvitationMessage("bob@example.com", "alice@example.com", testEvent)
+	if err != nil {
+		t.Fatalf("InvitationMessage() error = %v", err)
+	}
+	if err = sender.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	messages := server.received()
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+
+	parsed, err := netmail.ReadMessage(strings.NewReader(messages[0]))
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	if got := parsed.Header.Get("To"); got != "bob@example.com" {
+		t.Errorf("To = %v", got)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if subject != "Invitation: Planning <Q1>" {
+		t.Errorf("Subject = %v", subject)
+	}
+
+	parts := readParts(t, parsed.Header.Get("Content-Type"), parsed.Body)
+	if len(parts) != 2 {
+		t.Fatalf("Expected body and attachment, got %d parts", len(parts))
+	}
+	if !strings.HasPrefix(parts[0].contentType, "multipart/alternative") {
+		t.Errorf("First part is %v", parts[0].contentType)
+	}
+	alternatives := readParts(t, parts[0].contentType, strings.NewReader(parts[0].body))
+	if len(alternatives) != 2 {
+		t.Fatalf("Expected text and html, got %d parts", len(alternatives))
+	}
+	if !strings.Contains(alternatives[0].body, "alice@example.com invited you to Planning <Q1>") {
+		t.Errorf("Unexpected text body %q", alternatives[0].body)
+	}
+	if !strings.Contains(alternatives[1].body, "Planning &lt;Q1&gt;") {
+		t.Errorf("HTML body is not escaped %q", alternatives[1].body)
+	}
+
+	if !strings.HasPrefix(parts[1].contentType, "text/calendar") {
+		t.Errorf("Attachment is %v", parts[1].contentType)
+	}
+	if !strings.Contains(parts[1].body, "METHOD:REQUEST") || !strings.Contains(parts[1].body, "UID:1-2@http-calendar") {
+		t.Errorf("Unexpected calendar attachment %q", parts[1].body)
+	}
+}
+
+func TestSendRetried(t *testing.T) {
+	server := startFakeSMTP(t, 2, "421 Service not available")
+	sender := newTestSender(server)
+
+	msg, err := ReminderMessage("bob@example.com", testEvent)
+	if err != nil {
+		t.Fatalf("ReminderMessage() error = %v", err)
+	}
+	if err = sender.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got := len(server.received()); got != 1 {
+		t.Errorf("Expected 1 message, got %d", got)
+	}
+}
+
+func TestSendPermanentFailure(t *testing.T) {
+	server := startFakeSMTP(t, 10, "554 No service")
+	sender := newTestSender(server)
+
+	msg, err := AgendaMessage("bob@example.com", "Agenda", []models.Event{testEvent})
+	if err != nil {
+		t.Fatalf("AgendaMessage() error = %v", err)
+	}
+	if err = sender.Send(context.Background(), msg); err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.conns != 1 {
+		t.Errorf("Expected no retries of a permanent failure, got %d connections", server.conns)
+	}
+}
+
+func TestAgendaMessage_NoEvents(t *testing.T) {
+	msg, err := AgendaMessage("bob@example.com", "Agenda for Monday", nil)
+	if err != nil {
+		t.Fatalf("AgendaMessage() error = %v", err)
+	}
+	if !strings.Contains(msg.Text, "No events.") {
+		t.Errorf("Unexpected text %q", msg.Text)
+	}
+}
+
+type part struct {
+	contentType string
+	body        string
+}
+
+func readParts(t *testing.T, contentType string, body io.Reader) []part {
+	t.Helper()
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("ParseMediaType(%q) error = %v", contentType, err)
+	}
+
+	var parts []part
+	r := multipart.NewReader(body, params["boundary"])
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			return parts
+		}
+		if err != nil {
+			t.Fatalf("NextPart() error = %v", err)
+		}
+		data, err := io.ReadAll(p)
+		if err != nil {
+			t.Fatalf("ReadAll() error = %v", err)
+		}
+		if p.Header.Get("Content-Transfer-Encoding") == "base64" {
+			data = decodeBase64(t, string(data))
+		}
+		parts = append(parts, part{contentType: p.Header.Get("Content-Type"), body: string(data)})
+	}
+}
+
+func decodeBase64(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(s, "\r\n", ""))
+	if err != nil {
+		t.Fatalf("DecodeString() error = %v", err)
+	}
+	return data
+}
@@ -0,0 +1,121 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+type Message struct {
+	To          []string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Bytes renders the message as multipart/mixed with a multipart/alternative
+// body followed by the attachments.
+func (m *Message) Bytes(from string) ([]byte, error) {
+	var buf bytes.Buffer
+	mixed := multipart.NewWriter(&buf)
+
+	header := func(name, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+	header("From", from)
+	header("To", strings.Join(m.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/mixed; boundary="+mixed.Boundary())
+	buf.WriteString("\r\n")
+
+	altBody := &bytes.Buffer{}
+	alt := multipart.NewWriter(altBody)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		if part.body == "" {
+			continue
+		}
+		w, err := alt.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err = qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err = qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := alt.Close(); err != nil {
+		return nil, err
+	}
+
+	w, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alt.Boundary()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(altBody.Bytes()); err != nil {
+		return nil, err
+	}
+
+	for _, a := range m.Attachments {
+		mediaType, params, err := mime.ParseMediaType(a.ContentType)
+		if err != nil {
+			return nil, err
+		}
+		params["name"] = a.Name
+		w, err = mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(mediaType, params)},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Name})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err = writeBase64(w, a.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeBase64(w io.Writer, data []byte) error {
+	const lineLength = 76
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := min(lineLength, len(encoded))
+		if _, err := fmt.Fprintf(w, "%s\r\n", encoded[:n]); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
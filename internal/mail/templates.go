@@ -0,0 +1,120 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"http-calendar/internal/ical"
+	"http-calendar/internal/models"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+func when(event models.Event) string {
+	if event.Time == "" {
+		return event.Date.Format("Mon, 02 Jan 2006")
+	}
+	return event.Start().Format("Mon, 02 Jan 2006 15:04 MST")
+}
+
+var (
+	textTemplates = texttemplate.Must(texttemplate.New("").Funcs(texttemplate.FuncMap{"when": when}).ParseFS(templateFS, "templates/*.txt"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.New("").Funcs(htmltemplate.FuncMap{"when": when}).ParseFS(templateFS, "templates/*.html"))
+)
+
+func render(name string, data any) (string, string, error) {
+	var text, html bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&text, name+".txt", data); err != nil {
+		return "", "", err
+	}
+	if err := htmlTemplates.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return "", "", err
+	}
+	return text.String(), html.String(), nil
+}
+
+func calendarAttachment(method string, events ...models.Event) Attachment {
+	return Attachment{
+		Name:        "invite.ics",
+		ContentType: "text/calendar; charset=utf-8; method=" + method,
+		Data:        ical.Events(method, events...),
+	}
+}
+
+func schedulingAttachment(method, organizer string, event models.Event) Attachment {
+	return Attachment{
+		Name:        "invite.ics",
+		ContentType: "text/calendar; charset=utf-8; method=" + method,
+		Data:        ical.Scheduling(method, organizer, event),
+	}
+}
+
+func ReminderMessage(to string, event models.Event) (*Message, error) {
+	text, html, err := render("reminder", struct{ Event models.Event }{event})
+	if err != nil {
+		return nil, err
+	}
+	return &Message{
+		To:          []string{to},
+		Subject:     "Reminder: " + event.Title,
+		Text:        text,
+		HTML:        html,
+		Attachments: []Attachment{calendarAttachment(ical.MethodPublish, event)},
+	}, nil
+}
+
+func InvitationMessage(to, organizer string, event models.Event) (*Message, error) {
+	text, html, err := render("invitation", struct {
+		Organizer string
+		Event     models.Event
+	}{organizer, event})
+	if err != nil {
+		return nil, err
+	}
+	return &Message{
+		To:          []string{to},
+		Subject:     "Invitation: " + event.Title,
+		Text:        text,
+		HTML:        html,
+		Attachments: []Attachment{schedulingAttachment(ical.MethodRequest, organizer, event)},
+	}, nil
+}
+
+// ReplyMessage tells the organizer that attendee answered an invitation to
+// event with status.
+func ReplyMessage(to, attendee, status string, event models.Event) (*Message, error) {
+	text, html, err := render("reply", struct {
+		Attendee string
+		Status   string
+		Event    models.Event
+	}{attendee, status, event})
+	if err != nil {
+		return nil, err
+	}
+	return &Message{
+		To:          []string{to},
+		Subject:     "Reply: " + event.Title,
+		Text:        text,
+		HTML:        html,
+		Attachments: []Attachment{schedulingAttachment(ical.MethodReply, to, event)},
+	}, nil
+}
+
+func AgendaMessage(to, title string, events []models.Event) (*Message, error) {
+	text, html, err := render("agenda", struct {
+		Title  string
+		Events []models.Event
+	}{title, events})
+	if err != nil {
+		return nil, err
+	}
+	return &Message{
+		To:          []string{to},
+		Subject:     title,
+		Text:        text,
+		HTML:        html,
+		Attachments: []Attachment{calendarAttachment(ical.MethodPublish, events...)},
+	}, nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrAppointmentTypeNotFound = errors.New("appointment type not found")
+	ErrInvalidAppointmentType  = errors.New("invalid appointment type")
+	ErrSlotUnavailable         = errors.New("slot is not available")
+)
+
+// AppointmentType is a kind of meeting that external people book through a
+// public booking page, for example a 30-minute intro on weekday mornings.
+type AppointmentType struct {
+	AppointmentTypeID uint64 `json:"appointment_type_id"`
+	UserID            uint64 `json:"user_id"`
+	// Token identifies the public booking page of the appointment type.
+	Token       string `json:"token"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	// Duration is the length of an appointment in minutes.
+	Duration   int    `json:"duration"`
+	CalendarID uint64 `json:"calendar_id"`
+	TimeZone   string `json:"time_zone,omitempty"`
+	// Hours are the weekly windows in which appointments may take place.
+	Hours []WorkingHours `json:"hours"`
+	// Buffer is the number of minutes kept free before and after other
+	// events of the owner.
+	Buffer int `json:"buffer,omitempty"`
+	// MinimumNotice is the number of minutes an appointment has to be booked
+	// ahead of its start.
+	MinimumNotice int `json:"minimum_notice,omitempty"`
+	// DailyLimit caps the appointments of this type per day, 0 for no limit.
+	DailyLimit int `json:"daily_limit,omitempty"`
+}
+
+// Location returns the time zone of the hours, UTC by default.
+func (a AppointmentType) Location() *time.Location {
+	return Availability{TimeZone: a.TimeZone}.Location()
+}
+
+// BookingPage is the public view of an appointment type with its open slots.
+type BookingPage struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Duration    int        `json:"duration"`
+	TimeZone    string     `json:"time_zone,omitempty"`
+	Slots       []Interval `json:"slots"`
+}
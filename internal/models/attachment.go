@@ -0,0 +1,25 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrAttachmentNotFound    = errors.New("attachment not found")
+	ErrInvalidAttachment     = errors.New("invalid attachment")
+	ErrAttachmentTooLarge    = errors.New("attachment is too large")
+	ErrTooManyAttachments    = errors.New("too many attachments")
+	ErrAttachmentsNotEnabled = errors.New("attachments are not enabled")
+)
+
+// Attachment is a file attached to an event. Its content is stored once per
+// Hash, the hex SHA-256 of the content, however many events share it.
+type Attachment struct {
+	AttachmentID uint64    `json:"attachment_id"`
+	Name         string    `json:"name"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	Hash         string    `json:"hash"`
+	UploadedAt   time.Time `json:"uploaded_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "errors"
+
+var (
+	ErrInvalidAttendee = errors.New("invalid attendee")
+	ErrInvalidStatus   = errors.New("invalid participation status")
+	ErrNotInvited      = errors.New("not invited")
+	ErrMailDisabled    = errors.New("email is not configured")
+)
+
+const (
+	StatusNeedsAction = "needs-action"
+	StatusAccepted    = "accepted"
+	StatusDeclined    = "declined"
+	StatusTentative   = "tentative"
+)
+
+// Attendee is either a user of the calendar or an external email address.
+type Attendee struct {
+	UserID uint64 `json:"user_id,omitempty"`
+	Email  string `json:"email,omitempty"`
+	Status string `json:"status"`
+}
+
+// Same reports whether a and b refer to the same person.
+func (a Attendee) Same(b Attendee) bool {
+	if a.UserID != 0 || b.UserID != 0 {
+		return a.UserID == b.UserID
+	}
+	return a.Email == b.Email
+}
+
+// Declined reports whether userID declined the invitation to e.
+func (e Event) Declined(userID uint64) bool {
+	for _, attendee := range e.Attendees {
+		if attendee.UserID == userID {
+			return attendee.Status == StatusDeclined
+		}
+	}
+	return false
+}
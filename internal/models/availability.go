@@ -0,0 +1,50 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidWorkingHours = errors.New("invalid working hours")
+	ErrInvalidAutoDecline  = errors.New("invalid auto decline")
+	ErrOutOfOfficeNotFound = errors.New("out-of-office not found")
+)
+
+// Availability describes when a user can be booked. Without working hours
+// the user is available around the clock.
+type Availability struct {
+	UserID       uint64         `json:"user_id"`
+	TimeZone     string         `json:"time_zone,omitempty"`
+	WorkingHours []WorkingHours `json:"working_hours,omitempty"`
+	OutOfOffice  []OutOfOffice  `json:"out_of_office,omitempty"`
+	// AutoDecline declines invitations that overlap an out-of-office range.
+	AutoDecline bool `json:"auto_decline"`
+}
+
+// WorkingHours is a window on a weekday, "mon" to "sun", in the time zone of
+// the availability.
+type WorkingHours struct {
+	Day   string `json:"day"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type OutOfOffice struct {
+	ID    uint64    `json:"id"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Note  string    `json:"note,omitempty"`
+}
+
+// Location returns the time zone of the working hours, UTC by default.
+func (a Availability) Location() *time.Location {
+	if a.TimeZone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(a.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrCalendarNotFound = errors.New("calendar not found")
+	ErrExistingCalendar = errors.New("existing calendar")
+	ErrCalendarNotEmpty = errors.New("calendar has events")
+	ErrNameIsRequired   = errors.New("name is required")
+	ErrInvalidColor     = errors.New("invalid color")
+)
+
+// DefaultCalendarID is the calendar of events that were not put into a named
+// one. It always exists and can not be changed.
+const DefaultCalendarID = 0
+
+type Calendar struct {
+	UserID     uint64 `json:"user_id"`
+	CalendarID uint64 `json:"calendar_id"`
+	Name       string `json:"name"`
+	Color      string `json:"color,omitempty"`
+	// TimeZone is the zone the dates and times of the events of the calendar
+	// are read in, UTC when empty.
+	TimeZone string `json:"time_zone,omitempty"`
+	// ConflictMode applies to events of the calendar unless a request names
+	// one. Empty means ConflictAllow.
+	ConflictMode string `json:"conflict_mode,omitempty"`
+}
+
+// Location returns the time zone of the calendar, UTC by default.
+func (c Calendar) Location() *time.Location {
+	return Availability{TimeZone: c.TimeZone}.Location()
+}
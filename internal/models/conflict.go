@@ -0,0 +1,28 @@
+package models
+
+import "errors"
+
+var (
+	ErrConflict            = errors.New("event conflicts with existing events")
+	ErrInvalidConflictMode = errors.New("invalid conflict mode")
+)
+
+const (
+	ConflictAllow  = "allow"
+	ConflictWarn   = "warn"
+	ConflictReject = "reject"
+)
+
+// ConflictError is returned when an event was rejected because it overlaps
+// other events.
+type ConflictError struct {
+	Events []Event
+}
+
+func (e *ConflictError) Error() string {
+	return ErrConflict.Error()
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
@@ -0,0 +1,28 @@
+package models
+
+import "errors"
+
+var (
+	ErrFieldNotFound     = errors.New("field not found")
+	ErrInvalidField      = errors.New("invalid field definition")
+	ErrInvalidFieldValue = errors.New("invalid field value")
+	ErrFieldIsRequired   = errors.New("field is required")
+)
+
+// Types of custom fields.
+const (
+	FieldString = "string"
+	FieldNumber = "number"
+	FieldEnum   = "enum"
+	FieldDate   = "date"
+	FieldURL    = "url"
+)
+
+// FieldDefinition is a custom field that events of a calendar may or, when
+// Required, must have. Values lists the choices of an enum field.
+type FieldDefinition struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Values   []string `json:"values,omitempty"`
+	Required bool     `json:"required,omitempty"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidRange      = errors.New("invalid time range")
+	ErrInvalidSlotSearch = errors.New("invalid slot search")
+)
+
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type FreeBusy struct {
+	UserID uint64     `json:"user_id"`
+	Busy   []Interval `json:"busy"`
+	// Unavailable covers out-of-office time and the time outside working
+	// hours.
+	Unavailable []Interval `json:"unavailable,omitempty"`
+}
+
+type FreeBusyResult struct {
+	From  time.Time  `json:"from"`
+	To    time.Time  `json:"to"`
+	Users []FreeBusy `json:"users"`
+}
+
+type Slot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Score int       `json:"score"`
+}
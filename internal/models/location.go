@@ -0,0 +1,100 @@
+package models
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	ErrInvalidLocation = errors.New("invalid location")
+	ErrInvalidGeoQuery = errors.New("invalid location query")
+)
+
+const earthRadiusKm = 6371.0
+
+// Location is where an event takes place: an address, a point on the map,
+// a video call or any combination of them.
+type Location struct {
+	Address   string   `json:"address,omitempty"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	VideoURL  string   `json:"video_url,omitempty"`
+}
+
+// Point returns the coordinates of the location, if it has them.
+func (l *Location) Point() (Point, bool) {
+	if l == nil || l.Latitude == nil || l.Longitude == nil {
+		return Point{}, false
+	}
+	return Point{Latitude: *l.Latitude, Longitude: *l.Longitude}, true
+}
+
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// DistanceKm returns the great-circle distance between p and q.
+func (p Point) DistanceKm(q Point) float64 {
+	lat1, lat2 := p.Latitude*math.Pi/180, q.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (q.Longitude - p.Longitude) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// BoundingBox is an area between two latitudes and two longitudes. A box
+// with MinLongitude above MaxLongitude crosses the antimeridian.
+type BoundingBox struct {
+	MinLatitude  float64 `json:"min_latitude"`
+	MinLongitude float64 `json:"min_longitude"`
+	MaxLatitude  float64 `json:"max_latitude"`
+	MaxLongitude float64 `json:"max_longitude"`
+}
+
+func (b BoundingBox) Contains(p Point) bool {
+	if p.Latitude < b.MinLatitude || p.Latitude > b.MaxLatitude {
+		return false
+	}
+	if b.MinLongitude <= b.MaxLongitude {
+		return p.Longitude >= b.MinLongitude && p.Longitude <= b.MaxLongitude
+	}
+	return p.Longitude >= b.MinLongitude || p.Longitude <= b.MaxLongitude
+}
+
+// Around returns a box that contains every point within radiusKm of p.
+func Around(p Point, radiusKm float64) BoundingBox {
+	dLat := radiusKm / earthRadiusKm * 180 / math.Pi
+	box := BoundingBox{
+		MinLatitude:  math.Max(-90, p.Latitude-dLat),
+		MaxLatitude:  math.Min(90, p.Latitude+dLat),
+		MinLongitude: -180,
+		MaxLongitude: 180,
+	}
+	// Near the poles the circle spans all longitudes.
+	if box.MinLatitude > -90 && box.MaxLatitude < 90 {
+		ratio := math.Sin(radiusKm/earthRadiusKm) / math.Cos(p.Latitude*math.Pi/180)
+		if ratio < 1 {
+			dLon := math.Asin(ratio) * 180 / math.Pi
+			box.MinLongitude = wrapLongitude(p.Longitude - dLon)
+			box.MaxLongitude = wrapLongitude(p.Longitude + dLon)
+		}
+	}
+	return box
+}
+
+func wrapLongitude(lon float64) float64 {
+	switch {
+	case lon < -180:
+		return lon + 360
+	case lon > 180:
+		return lon - 360
+	}
+	return lon
+}
+
+// NearbyEvent is an event with its distance from the point of a query.
+type NearbyEvent struct {
+	Event      Event   `json:"event"`
+	DistanceKm float64 `json:"distance_km"`
+}
@@ -10,14 +10,49 @@ var (
 	ErrEventNotFound   = errors.New("event not found")
 	ErrTitleIsRequired = errors.New("title is required")
 	ErrExistingEvent   = errors.New("existing event")
+	ErrInvalidTime     = errors.New("invalid time")
+	ErrInvalidAlarm    = errors.New("invalid alarm")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrInvalidDuration = errors.New("invalid duration")
+	ErrEventChanged    = errors.New("event was changed concurrently")
 )
 
+const TimeFormat = "15:04"
+
+// DefaultDuration is the length of timed events without a duration.
+const DefaultDuration = time.Hour
+
 type Event struct {
-	UserID      uint64    `json:"user_id"`
-	EventID     uint64    `json:"event_id"`
-	Date        time.Time `json:"date"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
+	UserID      uint64       `json:"user_id"`
+	EventID     uint64       `json:"event_id"`
+	CalendarID  uint64       `json:"calendar_id"`
+	Date        time.Time    `json:"date"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Time        string       `json:"time,omitempty"`
+	Duration    int          `json:"duration,omitempty"`
+	Alarms      []Alarm      `json:"alarms,omitempty"`
+	Attendees   []Attendee   `json:"attendees,omitempty"`
+	Bookings    []Booking    `json:"bookings,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	Category    string       `json:"category,omitempty"`
+	Location    *Location    `json:"location,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// Fields holds the values of the custom fields of the calendar of the
+	// event, by field name.
+	Fields map[string]string `json:"fields,omitempty"`
+	// Status, Visibility and Transparency take the values of the Event*,
+	// Visibility* and Transparency* constants.
+	Status       string `json:"status,omitempty"`
+	Visibility   string `json:"visibility,omitempty"`
+	Transparency string `json:"transparency,omitempty"`
+	// AppointmentTypeID is set on events booked through a booking page.
+	AppointmentTypeID uint64 `json:"appointment_type_id,omitempty"`
+}
+
+type Alarm struct {
+	MinutesBefore int `json:"minutes_before"`
 }
 
 func NewEvent(userID uint64, eventID uint64, date time.Time, title, description string) *Event {
@@ -29,3 +64,53 @@ func NewEvent(userID uint64, eventID uint64, date time.Time, title, description
 		Description: description,
 	}
 }
+
+// Start returns the moment the event begins. Events without a time start at
+// the beginning of their day.
+func (e Event) Start() time.Time {
+	start := time.Date(e.Date.Year(), e.Date.Month(), e.Date.Day(), 0, 0, 0, 0, e.Date.Location())
+	if e.Time == "" {
+		return start
+	}
+	t, err := time.Parse(TimeFormat, e.Time)
+	if err != nil {
+		return start
+	}
+	return start.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute)
+}
+
+// End returns the moment the event is over. All-day events last until the
+// next day, timed events for their duration in minutes.
+func (e Event) End() time.Time {
+	if e.Time == "" {
+		day := time.Date(e.Date.Year(), e.Date.Month(), e.Date.Day(), 0, 0, 0, 0, e.Date.Location())
+		return day.AddDate(0, 0, 1)
+	}
+	if e.Duration <= 0 {
+		return e.Start().Add(DefaultDuration)
+	}
+	return e.Start().Add(time.Duration(e.Duration) * time.Minute)
+}
+
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+type Change struct {
+	ID     uint64    `json:"id"`
+	Type   string    `json:"type"`
+	UserID uint64    `json:"user_id"`
+	Event  Event     `json:"event"`
+	Time   time.Time `json:"time"`
+}
+
+type Reminder struct {
+	UserID        uint64    `json:"user_id"`
+	EventID       uint64    `json:"event_id"`
+	Title         string    `json:"title"`
+	Start         time.Time `json:"start"`
+	MinutesBefore int       `json:"minutes_before"`
+	FireAt        time.Time `json:"fire_at"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "errors"
+
+var (
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrResourceBusy     = errors.New("resource is already booked")
+	ErrResourceInUse    = errors.New("resource has bookings")
+	ErrCapacityExceeded = errors.New("resource capacity exceeded")
+	ErrInvalidResource  = errors.New("invalid resource")
+)
+
+const (
+	BookingConfirmed = "confirmed"
+	BookingPending   = "pending"
+	BookingRejected  = "rejected"
+)
+
+// Resource is a room, a piece of equipment or a vehicle that events book.
+// Its bookings form a calendar of their own.
+type Resource struct {
+	ResourceID uint64 `json:"resource_id"`
+	// OwnerID manages the resource and approves its bookings.
+	OwnerID uint64 `json:"owner_id"`
+	Name    string `json:"name"`
+	Kind    string `json:"kind,omitempty"`
+	// Capacity is the number of people the resource holds, 0 for no limit.
+	Capacity int `json:"capacity,omitempty"`
+	// Quantity is the number of bookings that may overlap, for example the
+	// size of a pool of projectors.
+	Quantity         int  `json:"quantity"`
+	ApprovalRequired bool `json:"approval_required"`
+}
+
+type Booking struct {
+	ResourceID uint64 `json:"resource_id"`
+	Status     string `json:"status"`
+}
+
+// ResourceBusyError names the resource that could not be booked.
+type ResourceBusyError struct {
+	ResourceID uint64
+}
+
+func (e *ResourceBusyError) Error() string {
+	return ErrResourceBusy.Error()
+}
+
+func (e *ResourceBusyError) Unwrap() error {
+	return ErrResourceBusy
+}
+
+type ResourceAvailability struct {
+	ResourceID uint64     `json:"resource_id"`
+	Busy       []Interval `json:"busy"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "errors"
+
+var ErrInvalidQuery = errors.New("invalid search query")
+
+type SearchResult struct {
+	Event Event   `json:"event"`
+	Score float64 `json:"score"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrShareNotFound      = errors.New("share not found")
+	ErrInvalidAccessLevel = errors.New("invalid access level")
+	ErrInvalidShare       = errors.New("can not share a calendar with its owner")
+)
+
+const (
+	AccessFreeBusy = "freebusy"
+	AccessRead     = "read"
+	AccessWrite    = "write"
+)
+
+type Share struct {
+	OwnerID   uint64    `json:"owner_id"`
+	GranteeID uint64    `json:"grantee_id"`
+	Level     string    `json:"level"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Shares struct {
+	Granted  []Share `json:"granted"`
+	Received []Share `json:"received"`
+}
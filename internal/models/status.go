@@ -0,0 +1,38 @@
+package models
+
+import "errors"
+
+var (
+	ErrInvalidEventStatus  = errors.New("invalid event status")
+	ErrInvalidVisibility   = errors.New("invalid visibility")
+	ErrInvalidTransparency = errors.New("invalid transparency")
+)
+
+// Statuses of an event. Events without one are confirmed.
+const (
+	EventTentative = "tentative"
+	EventConfirmed = "confirmed"
+	EventCancelled = "cancelled"
+)
+
+// Visibilities of an event for users the calendar is shared with. Events
+// without one are public.
+const (
+	VisibilityPublic = "public"
+	// VisibilityPrivate shows the event as busy time only.
+	VisibilityPrivate = "private"
+	// VisibilityConfidential shows the title and time but no other details.
+	VisibilityConfidential = "confidential"
+)
+
+// Transparencies of an event. Events without one are opaque.
+const (
+	TransparencyOpaque      = "opaque"
+	TransparencyTransparent = "transparent"
+)
+
+// Busy reports whether the event blocks time in free/busy and conflict
+// checks: it is neither transparent nor cancelled.
+func (e Event) Busy() bool {
+	return e.Transparency != TransparencyTransparent && e.Status != EventCancelled
+}
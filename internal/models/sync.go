@@ -0,0 +1,19 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidSyncToken = errors.New("invalid sync token")
+
+type Tombstone struct {
+	EventID   uint64    `json:"event_id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type SyncResult struct {
+	SyncToken string      `json:"sync_token"`
+	Events    []Event     `json:"events"`
+	Deleted   []Tombstone `json:"deleted"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "errors"
+
+var (
+	ErrInvalidTag      = errors.New("invalid tag")
+	ErrInvalidCategory = errors.New("invalid category")
+	ErrInvalidTagMode  = errors.New("invalid tag mode")
+)
+
+const (
+	TagModeAny = "any"
+	TagModeAll = "all"
+)
+
+// TagCount is the number of events of a user that carry a tag.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
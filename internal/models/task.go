@@ -0,0 +1,28 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrTaskNotFound    = errors.New("task not found")
+	ErrInvalidPriority = errors.New("invalid priority")
+	ErrInvalidDue      = errors.New("invalid due date")
+)
+
+// Task is a to-do with the semantics of an iCalendar VTODO. It lives in a
+// calendar next to the events of its owner.
+type Task struct {
+	UserID      uint64     `json:"user_id"`
+	TaskID      uint64     `json:"task_id"`
+	CalendarID  uint64     `json:"calendar_id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Due         *time.Time `json:"due,omitempty"`
+	// Priority ranges from 1, the highest, to 9, the lowest. 0 means
+	// undefined.
+	Priority    int        `json:"priority,omitempty"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrNotInTrash = errors.New("event not in trash")
+
+// TrashedEvent is a deleted event that can be restored until PurgeAt, when
+// it is removed for good.
+type TrashedEvent struct {
+	Event     Event     `json:"event"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
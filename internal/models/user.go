@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidEmail    = errors.New("invalid email")
+	ErrInvalidTimeZone = errors.New("invalid time zone")
+	ErrInvalidDigest   = errors.New("invalid digest settings")
+)
+
+const (
+	PeriodDay  = "day"
+	PeriodWeek = "week"
+)
+
+type User struct {
+	UserID   uint64         `json:"user_id"`
+	Email    string         `json:"email,omitempty"`
+	TimeZone string         `json:"time_zone,omitempty"`
+	Digest   DigestSettings `json:"digest"`
+}
+
+// DigestSettings describe when and where the agenda digest of a user is
+// delivered. An empty Period disables it.
+type DigestSettings struct {
+	Period  string `json:"period,omitempty"`
+	Time    string `json:"time,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	Target  string `json:"target,omitempty"`
+}
+
+func (u User) Location() *time.Location {
+	if u.TimeZone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(u.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+type Digest struct {
+	UserID uint64    `json:"user_id"`
+	Period string    `json:"period"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Events []Event   `json:"events"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrInvalidURL      = errors.New("invalid url")
+	ErrPrivateURL      = errors.New("url points to a non-public address")
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+type Webhook struct {
+	WebhookID uint64    `json:"webhook_id"`
+	UserID    uint64    `json:"user_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Delivery struct {
+	DeliveryID  uint64            `json:"delivery_id"`
+	WebhookID   uint64            `json:"webhook_id"`
+	UserID      uint64            `json:"user_id"`
+	URL         string            `json:"url"`
+	Change      Change            `json:"change"`
+	Status      string            `json:"status"`
+	NextAttempt time.Time         `json:"next_attempt"`
+	Attempts    []DeliveryAttempt `json:"attempts"`
+}
+
+type DeliveryAttempt struct {
+	Time       time.Time `json:"time"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
@@ -0,0 +1,163 @@
+package pubsub
+
+import (
+	"http-calendar/internal/models"
+	"sync"
+	"time"
+)
+
+const (
+	LogSize    = 1024
+	bufferSize = 64
+)
+
+var hub struct {
+	mu     sync.Mutex
+	nextID uint64
+	log    []models.Change
+	subs   map[uint64]map[chan models.Change]struct{}
+	all    map[chan models.Change]struct{}
+	closed bool
+}
+
+// Publish records the change in the bounded log and fans it out to the
+// subscribers of the event owner. Subscribers that can not keep up are
+// dropped; they are expected to reconnect and resume from the log.
+func Publish(changeType string, event models.Event) models.Change {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.nextID++
+	change := models.Change{
+		ID:     hub.nextID,
+		Type:   changeType,
+		UserID: event.UserID,
+		Event:  event,
+		Time:   time.Now(),
+	}
+
+	if len(hub.log) == LogSize {
+		copy(hub.log, hub.log[1:])
+		hub.log = hub.log[:LogSize-1]
+	}
+	hub.log = append(hub.log, change)
+
+	for ch := range hub.subs[change.UserID] {
+		select {
+		case ch <- change:
+		default:
+			delete(hub.subs[change.UserID], ch)
+			close(ch)
+		}
+	}
+	for ch := range hub.all {
+		select {
+		case ch <- change:
+		default:
+			delete(hub.all, ch)
+			close(ch)
+		}
+	}
+	return change
+}
+
+// Subscribe registers a listener for the changes of userID. Changes newer
+// than lastID still present in the log are returned as backlog; complete is
+// false when some of them have already been evicted.
+func Subscribe(userID, lastID uint64) (backlog []models.Change, complete bool, ch <-chan models.Change, cancel func()) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	complete = true
+	if lastID > 0 && len(hub.log) > 0 && hub.log[0].ID > lastID+1 {
+		complete = false
+	}
+	for _, change := range hub.log {
+		if change.ID > lastID && change.UserID == userID {
+			backlog = append(backlog, change)
+		}
+	}
+
+	c := make(chan models.Change, bufferSize)
+	if hub.closed {
+		close(c)
+		return backlog, complete, c, func() {}
+	}
+	if hub.subs == nil {
+		hub.subs = make(map[uint64]map[chan models.Change]struct{})
+	}
+	if hub.subs[userID] == nil {
+		hub.subs[userID] = make(map[chan models.Change]struct{})
+	}
+	hub.subs[userID][c] = struct{}{}
+
+	cancel = func() {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		if _, ok := hub.subs[userID][c]; ok {
+			delete(hub.subs[userID], c)
+			close(c)
+		}
+	}
+	return backlog, complete, c, cancel
+}
+
+// SubscribeAll registers a listener for the changes of every user. Like with
+// Subscribe, the channel is closed when the listener falls behind. After
+// Close the returned channel is nil.
+func SubscribeAll(buffer int) (ch <-chan models.Change, cancel func()) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.closed {
+		return nil, func() {}
+	}
+	c := make(chan models.Change, buffer)
+	if hub.all == nil {
+		hub.all = make(map[chan models.Change]struct{})
+	}
+	hub.all[c] = struct{}{}
+
+	cancel = func() {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		if _, ok := hub.all[c]; ok {
+			delete(hub.all, c)
+			close(c)
+		}
+	}
+	return c, cancel
+}
+
+// Close ends every subscription so that streaming handlers return and the
+// http server can shut down.
+func Close() {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.closed = true
+	closeAll()
+}
+
+func Clear() {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	closeAll()
+	hub.nextID = 0
+	hub.log = nil
+	hub.closed = false
+}
+
+func closeAll() {
+	for _, subs := range hub.subs {
+		for ch := range subs {
+			close(ch)
+		}
+	}
+	for ch := range hub.all {
+		close(ch)
+	}
+	hub.subs = nil
+	hub.all = nil
+}
@@ -0,0 +1,123 @@
+package pubsub
+
+import (
+	"http-calendar/internal/models"
+	"testing"
+)
+
+func TestPublishSubscribe(t *testing.T) {
+	Clear()
+
+	_, _, ch, cancel := Subscribe(1, 0)
+	defer cancel()
+
+	Publish(models.ChangeCreated, models.Event{UserID: 2, EventID: 1})
+	Publish(models.ChangeCreated, models.Event{UserID: 1, EventID: 2})
+
+	change := <-ch
+	if change.Event.EventID != 2 || change.Type != models.ChangeCreated {
+		t.Errorf("Unexpected change %+v", change)
+	}
+	select {
+	case change = <-ch:
+		t.Errorf("Received change of another user %+v", change)
+	default:
+	}
+}
+
+func TestSubscribe_Resume(t *testing.T) {
+	Clear()
+
+	first := Publish(models.ChangeCreated, models.Event{UserID: 1, EventID: 1})
+	Publish(models.ChangeUpdated, models.Event{UserID: 1, EventID: 1})
+	Publish(models.ChangeDeleted, models.Event{UserID: 1, EventID: 1})
+
+	backlog, complete, _, cancel := Subscribe(1, first.ID)
+	defer cancel()
+
+	if !complete {
+		t.Errorf("Expected complete backlog")
+	}
+	if len(backlog) != 2 {
+		t.Fatalf("Expected 2 changes, got %d", len(backlog))
+	}
+	if backlog[0].Type != models.ChangeUpdated || backlog[1].Type != models.ChangeDeleted {
+		t.Errorf("Unexpected backlog %+v", backlog)
+	}
+}
+
+func TestSubscribe_EvictedHistory(t *testing.T) {
+	Clear()
+
+	for i := 0; i < LogSize+10; i++ {
+		Publish(models.ChangeCreated, models.Event{UserID: 1, EventID: uint64(i)})
+	}
+
+	backlog, complete, _, cancel := Subscribe(1, 5)
+	defer cancel()
+
+	if complete {
+		t.Errorf("Expected incomplete backlog")
+	}
+	if len(backlog) != LogSize {
+		t.Errorf("Expected %d changes, got %d", LogSize, len(backlog))
+	}
+}
+
+func TestSlowSubscriberDropped(t *testing.T) {
+	Clear()
+
+	_, _, ch, cancel := Subscribe(1, 0)
+	defer cancel()
+
+	for i := 0; i < bufferSize+1; i++ {
+		Publish(models.ChangeCreated, models.Event{UserID: 1, EventID: uint64(i)})
+	}
+
+	n := 0
+	for range ch {
+		n++
+	}
+	if n != bufferSize {
+		t.Errorf("Expected %d buffered changes, got %d", bufferSize, n)
+	}
+}
+
+func TestClose(t *testing.T) {
+	Clear()
+
+	_, _, ch, cancel := Subscribe(1, 0)
+	defer cancel()
+
+	Close()
+	if _, ok := <-ch; ok {
+		t.Errorf("Expected closed channel")
+	}
+	Clear()
+}
+
+func TestSubscribeAll(t *testing.T) {
+	Clear()
+
+	ch, cancel := SubscribeAll(bufferSize)
+	defer cancel()
+
+	Publish(models.ChangeCreated, models.Event{UserID: 1, EventID: 1})
+	Publish(models.ChangeCreated, models.Event{UserID: 2, EventID: 2})
+
+	if change := <-ch; change.UserID != 1 {
+		t.Errorf("Unexpected change %+v", change)
+	}
+	if change := <-ch; change.UserID != 2 {
+		t.Errorf("Unexpected change %+v", change)
+	}
+
+	Close()
+	if _, ok := <-ch; ok {
+		t.Errorf("Expected closed channel")
+	}
+	if ch, _ = SubscribeAll(bufferSize); ch != nil {
+		t.Errorf("Expected nil channel after Close")
+	}
+	Clear()
+}
@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"http-calendar/internal/mail"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"log"
+	"net/http"
+)
+
+type Notifier interface {
+	Notify(ctx context.Context, reminder models.Reminder) error
+}
+
+// Notifiers delivers every reminder through each of its notifiers.
+type Notifiers []Notifier
+
+func (n Notifiers) Notify(ctx context.Context, reminder models.Reminder) error {
+	var errs []error
+	for _, notifier := range n {
+		if err := notifier.Notify(ctx, reminder); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(_ context.Context, reminder models.Reminder) error {
+	log.Printf("reminder for user %d: %q starts at %s\n", reminder.UserID, reminder.Title, reminder.Start.Format("2006-01-02 15:04"))
+	return nil
+}
+
+// WebhookNotifier posts reminders as JSON to a fixed URL.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (n WebhookNotifier) Notify(ctx context.Context, reminder models.Reminder) error {
+	body, err := json.Marshal(reminder)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = resp.Body.Close(); err != nil {
+			log.Printf("Error closing reminder webhook response: %v\n", err)
+		}
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("reminder webhook: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// EmailNotifier mails reminders to the address returned by Recipient. Users
+// without an address are skipped.
+type EmailNotifier struct {
+	Sender    *mail.Sender
+	Recipient func(userID uint64) (string, bool)
+}
+
+func (n EmailNotifier) Notify(ctx context.Context, reminder models.Reminder) error {
+	to, ok := n.Recipient(reminder.UserID)
+	if !ok {
+		return nil
+	}
+
+	event, err := storage.GetEvent(reminder.UserID, reminder.EventID)
+	if err != nil {
+		return err
+	}
+	msg, err := mail.ReminderMessage(to, event)
+	if err != nil {
+		return err
+	}
+	return n.Sender.Send(ctx, msg)
+}
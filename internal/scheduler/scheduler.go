@@ -0,0 +1,236 @@
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"http-calendar/internal/models"
+	"http-calendar/internal/pubsub"
+	"http-calendar/internal/storage"
+	"log"
+	"time"
+)
+
+const (
+	// missedGrace lets alarms that became due a moment ago still fire, for
+	// example when an event is created shortly before its alarm time.
+	missedGrace   = time.Minute
+	notifyTimeout = 10 * time.Second
+	idleWait      = time.Hour
+	changesBuffer = 1024
+	// compactMin keeps small queues from being compacted over and over.
+	compactMin = 64
+)
+
+type eventKey struct {
+	userID  uint64
+	eventID uint64
+}
+
+// alarmKey names one alarm of one occurrence of an event.
+type alarmKey struct {
+	event         eventKey
+	minutesBefore int
+	start         int64
+}
+
+type item struct {
+	reminder models.Reminder
+	key      eventKey
+	version  uint64
+}
+
+type reminderQueue []item
+
+func (q reminderQueue) Len() int           { return len(q) }
+func (q reminderQueue) Less(i, j int) bool { return q[i].reminder.FireAt.Before(q[j].reminder.FireAt) }
+func (q reminderQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *reminderQueue) Push(x any)        { *q = append(*q, x.(item)) }
+func (q *reminderQueue) Pop() any {
+	old := *q
+	n := len(old)
+	it := old[n-1]
+	*q = old[:n-1]
+	return it
+}
+
+// Scheduler fires event alarms through a Notifier. Its queue is only touched
+// by the Run goroutine. Fired alarms are remembered while they are within
+// missedGrace, so that rebuilding or rescheduling does not fire them again.
+type Scheduler struct {
+	notifier Notifier
+	now      func() time.Time
+	queue    reminderQueue
+	versions map[eventKey]uint64
+	version  uint64
+	fired    map[alarmKey]time.Time
+	// pending counts the queued items of the current version of each event,
+	// live their sum.
+	pending map[eventKey]int
+	live    int
+}
+
+func New(notifier Notifier) *Scheduler {
+	return &Scheduler{
+		notifier: notifier,
+		now:      time.Now,
+		versions: make(map[eventKey]uint64),
+		fired:    make(map[alarmKey]time.Time),
+		pending:  make(map[eventKey]int),
+	}
+}
+
+// Run rebuilds the queue from storage, keeps it in sync with event changes
+// and fires due alarms until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	changes, cancel := pubsub.SubscribeAll(changesBuffer)
+	defer func() { cancel() }()
+	s.rebuild()
+
+	timer := time.NewTimer(idleWait)
+	defer timer.Stop()
+
+	for {
+		wait := idleWait
+		if s.queue.Len() > 0 {
+			wait = s.queue[0].reminder.FireAt.Sub(s.now())
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-ctx.Done():
+			return
+		case change, ok := <-changes:
+			if !ok {
+				// Fell behind the hub; start over from storage.
+				cancel()
+				changes, cancel = pubsub.SubscribeAll(changesBuffer)
+				s.rebuild()
+				continue
+			}
+			s.apply(change)
+		case <-timer.C:
+			s.fire(ctx)
+		}
+	}
+}
+
+func (s *Scheduler) rebuild() {
+	s.queue = s.queue[:0]
+	s.versions = make(map[eventKey]uint64)
+	s.pending = make(map[eventKey]int)
+	s.live = 0
+	for _, event := range storage.GetAllEvents() {
+		s.schedule(event)
+	}
+}
+
+func (s *Scheduler) apply(change models.Change) {
+	if change.Type == models.ChangeDeleted {
+		s.unschedule(eventKey{change.Event.UserID, change.Event.EventID})
+		return
+	}
+	s.schedule(change.Event)
+}
+
+// schedule replaces the pending alarms of the event with its current ones.
+// Items of earlier versions stay in the heap and are skipped when popped, or
+// dropped by compact once they make up half of it.
+func (s *Scheduler) schedule(event models.Event) {
+	key := eventKey{event.UserID, event.EventID}
+	s.unschedule(key)
+	if len(event.Alarms) == 0 {
+		return
+	}
+
+	s.version++
+	s.versions[key] = s.version
+
+	now := s.now()
+	start := event.Start()
+	for _, alarm := range event.Alarms {
+		fireAt := start.Add(-time.Duration(alarm.MinutesBefore) * time.Minute)
+		if fireAt.Before(now.Add(-missedGrace)) {
+			continue
+		}
+		if _, ok := s.fired[alarmKey{key, alarm.MinutesBefore, start.UnixNano()}]; ok {
+			continue
+		}
+		heap.Push(&s.queue, item{
+			reminder: models.Reminder{
+				UserID:        event.UserID,
+				EventID:       event.EventID,
+				Title:         event.Title,
+				Start:         start,
+				MinutesBefore: alarm.MinutesBefore,
+				FireAt:        fireAt,
+			},
+			key:     key,
+			version: s.version,
+		})
+		s.pending[key]++
+		s.live++
+	}
+}
+
+func (s *Scheduler) unschedule(key eventKey) {
+	delete(s.versions, key)
+	s.live -= s.pending[key]
+	delete(s.pending, key)
+	s.compact()
+}
+
+// compact removes the items of earlier versions from the queue when they
+// outnumber the live ones, so that events whose alarms keep moving into the
+// future do not grow it without bound.
+func (s *Scheduler) compact() {
+	if len(s.queue) < compactMin || s.live*2 >= len(s.queue) {
+		return
+	}
+	queue := s.queue[:0]
+	for _, it := range s.queue {
+		if s.versions[it.key] == it.version {
+			queue = append(queue, it)
+		}
+	}
+	clear(s.queue[len(queue):])
+	s.queue = queue
+	heap.Init(&s.queue)
+}
+
+// due pops the reminders that should fire at now.
+func (s *Scheduler) due(now time.Time) []models.Reminder {
+	var result []models.Reminder
+	for s.queue.Len() > 0 && !s.queue[0].reminder.FireAt.After(now) {
+		it := heap.Pop(&s.queue).(item)
+		if s.versions[it.key] != it.version {
+			continue
+		}
+		s.pending[it.key]--
+		s.live--
+		fired := alarmKey{it.key, it.reminder.MinutesBefore, it.reminder.Start.UnixNano()}
+		if _, ok := s.fired[fired]; ok {
+			continue
+		}
+		s.fired[fired] = it.reminder.FireAt
+		result = append(result, it.reminder)
+	}
+	// Alarms past the grace are not scheduled anymore, so they need not be
+	// remembered.
+	for key, fireAt := range s.fired {
+		if fireAt.Before(now.Add(-missedGrace)) {
+			delete(s.fired, key)
+		}
+	}
+	return result
+}
+
+func (s *Scheduler) fire(ctx context.Context) {
+	for _, reminder := range s.due(s.now()) {
+		notifyCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+		err := s.notifier.Notify(notifyCtx, reminder)
+		cancel()
+		if err != nil {
+			log.Printf("Failed to notify reminder for event %d: %v\n", reminder.EventID, err)
+		}
+	}
+}
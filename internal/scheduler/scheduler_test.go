@@ -0,0 +1,201 @@
+package scheduler
+
+import (
+	"context"
+	"http-calendar/internal/models"
+	"http-calendar/internal/pubsub"
+	"http-calendar/internal/storage"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	mu        sync.Mutex
+	reminders []models.Reminder
+}
+
+func (r *recorder) Notify(_ context.Context, reminder models.Reminder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reminders = append(r.reminders, reminder)
+	return nil
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.reminders)
+}
+
+var day = time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+func newTestScheduler(now time.Time) *Scheduler {
+	s := New(&recorder{})
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestScheduleOrdersAlarms(t *testing.T) {
+	s := newTestScheduler(day)
+
+	s.schedule(models.Event{UserID: 1, EventID: 1, Date: day, Time: "10:00", Alarms: []models.Alarm{{MinutesBefore: 15}, {MinutesBefore: 60}}})
+	s.schedule(models.Event{UserID: 1, EventID: 2, Date: day, Time: "09:30", Alarms: []models.Alarm{{MinutesBefore: 0}}})
+
+	got := s.due(day.Add(24 * time.Hour))
+	want := []time.Time{
+		day.Add(9 * time.Hour),
+		day.Add(9*time.Hour + 30*time.Minute),
+		day.Add(9*time.Hour + 45*time.Minute),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d reminders, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if !got[i].FireAt.Equal(want[i]) {
+			t.Errorf("Reminder[%d] fires at %v, want %v", i, got[i].FireAt, want[i])
+		}
+	}
+}
+
+func TestScheduleNotYetDue(t *testing.T) {
+	s := newTestScheduler(day)
+
+	s.schedule(models.Event{UserID: 1, EventID: 1, Date: day, Time: "10:00", Alarms: []models.Alarm{{MinutesBefore: 15}}})
+
+	if got := s.due(day.Add(9 * time.Hour)); len(got) != 0 {
+		t.Errorf("Expected no due reminders, got %d", len(got))
+	}
+	if got := s.due(day.Add(10 * time.Hour)); len(got) != 1 {
+		t.Errorf("Expected 1 due reminder, got %d", len(got))
+	}
+}
+
+func TestScheduleSkipsPastAlarms(t *testing.T) {
+	s := newTestScheduler(day.Add(12 * time.Hour))
+
+	s.schedule(models.Event{UserID: 1, EventID: 1, Date: day, Time: "10:00", Alarms: []models.Alarm{{MinutesBefore: 15}}})
+
+	if s.queue.Len() != 0 {
+		t.Errorf("Expected empty queue, got %d", s.queue.Len())
+	}
+}
+
+func TestRescheduleReplacesAlarms(t *testing.T) {
+	s := newTestScheduler(day)
+
+	event := models.Event{UserID: 1, EventID: 1, Date: day, Time: "10:00", Alarms: []models.Alarm{{MinutesBefore: 15}}}
+	s.schedule(event)
+	event.Time = "11:00"
+	s.apply(models.Change{Type: models.ChangeUpdated, Event: event})
+
+	got := s.due(day.Add(24 * time.Hour))
+	if len(got) != 1 || !got[0].FireAt.Equal(day.Add(10*time.Hour+45*time.Minute)) {
+		t.Errorf("Expected only the rescheduled reminder, got %+v", got)
+	}
+
+	s.schedule(event)
+	s.apply(models.Change{Type: models.ChangeDeleted, Event: event})
+	if got = s.due(day.Add(24 * time.Hour)); len(got) != 0 {
+		t.Errorf("Expected no reminders after delete, got %+v", got)
+	}
+}
+
+func TestRescheduleCompactsQueue(t *testing.T) {
+	s := newTestScheduler(day)
+
+	other := models.Event{UserID: 1, EventID: 2, Date: day, Time: "12:00", Alarms: []models.Alarm{{MinutesBefore: 5}}}
+	s.schedule(other)
+	event := models.Event{UserID: 1, EventID: 1, Date: day, Time: "10:00", Alarms: []models.Alarm{{MinutesBefore: 15}}}
+	for i := 1; i <= 1000; i++ {
+		event.Date = day.AddDate(0, 0, i)
+		s.apply(models.Change{Type: models.ChangeUpdated, Event: event})
+	}
+
+	if s.queue.Len() > compactMin {
+		t.Errorf("Expected stale items to be dropped, queue has %d", s.queue.Len())
+	}
+	got := s.due(day.AddDate(0, 0, 2000))
+	if len(got) != 2 || got[0].EventID != 2 || !got[1].Start.Equal(day.AddDate(0, 0, 1000).Add(10*time.Hour)) {
+		t.Errorf("Expected the other and the last reminder, got %+v", got)
+	}
+}
+
+func TestRebuildSkipsFiredAlarms(t *testing.T) {
+	storage.Clear()
+
+	start := day.Add(10 * time.Hour)
+	err := storage.CreateEvent(&models.Event{UserID: 1, EventID: 1, Date: day, Time: "10:00",
+		Alarms: []models.Alarm{{MinutesBefore: 0}, {MinutesBefore: 1}}})
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+
+	s := newTestScheduler(start.Add(-time.Minute))
+	s.rebuild()
+	if got := s.due(start.Add(-time.Minute)); len(got) != 1 || got[0].MinutesBefore != 1 {
+		t.Fatalf("Expected the first alarm, got %+v", got)
+	}
+
+	// Still within the grace of the fired alarm.
+	s.now = func() time.Time { return start }
+	s.rebuild()
+	got := s.due(start)
+	if len(got) != 1 || got[0].MinutesBefore != 0 {
+		t.Errorf("Expected only the alarm not fired yet, got %+v", got)
+	}
+
+	s.rebuild()
+	if got = s.due(start); len(got) != 0 {
+		t.Errorf("Expected no reminders after another rebuild, got %+v", got)
+	}
+}
+
+func TestRunFiresAlarms(t *testing.T) {
+	storage.Clear()
+	pubsub.Clear()
+
+	start := time.Now().Add(2 * time.Minute).Truncate(time.Minute)
+	date := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+
+	// Present before the scheduler starts, picked up by the rebuild.
+	err := storage.CreateEvent(&models.Event{UserID: 1, EventID: 1, Date: date, Time: start.Format(models.TimeFormat), Title: "Existing",
+		Alarms: []models.Alarm{{MinutesBefore: 2}}})
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+
+	rec := &recorder{}
+	s := New(rec)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for rec.count() < 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Reminder of existing event did not fire")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Created while running, delivered through the change hub.
+	err = storage.CreateEvent(&models.Event{UserID: 1, EventID: 2, Date: date, Time: start.Format(models.TimeFormat), Title: "New",
+		Alarms: []models.Alarm{{MinutesBefore: 2}}})
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	for rec.count() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Reminder of new event did not fire")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
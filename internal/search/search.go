@@ -0,0 +1,165 @@
+// Package search implements an in-memory inverted index for full-text
+// search with prefix matching and TF-IDF ranking.
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// prefixWeight scales the score of a term that only starts with a query
+// term, so exact matches rank higher.
+const prefixWeight = 0.5
+
+// Field is a piece of text of a document. Its terms count Weight times.
+type Field struct {
+	Text   string
+	Weight float64
+}
+
+type Hit struct {
+	ID    uint64
+	Score float64
+}
+
+// Index maps terms to the documents that contain them. It is not safe for
+// concurrent use.
+type Index struct {
+	postings map[string]map[uint64]float64
+	docs     map[uint64][]string
+	// terms holds the keys of postings in order, for prefix lookups.
+	terms []string
+}
+
+func NewIndex() *Index {
+	return &Index{
+		postings: make(map[string]map[uint64]float64),
+		docs:     make(map[uint64][]string),
+	}
+}
+
+// Len returns the number of indexed documents.
+func (i *Index) Len() int {
+	return len(i.docs)
+}
+
+// Add indexes the fields of document id, replacing what was indexed for it.
+func (i *Index) Add(id uint64, fields ...Field) {
+	i.Remove(id)
+
+	weights := make(map[string]float64)
+	for _, field := range fields {
+		for _, term := range Tokenize(field.Text) {
+			weights[term] += field.Weight
+		}
+	}
+	if len(weights) == 0 {
+		return
+	}
+
+	terms := make([]string, 0, len(weights))
+	for term, weight := range weights {
+		if i.postings[term] == nil {
+			i.postings[term] = make(map[uint64]float64)
+			i.insertTerm(term)
+		}
+		i.postings[term][id] = weight
+		terms = append(terms, term)
+	}
+	i.docs[id] = terms
+}
+
+// Remove drops document id from the index.
+func (i *Index) Remove(id uint64) {
+	for _, term := range i.docs[id] {
+		delete(i.postings[term], id)
+		if len(i.postings[term]) == 0 {
+			delete(i.postings, term)
+			i.removeTerm(term)
+		}
+	}
+	delete(i.docs, id)
+}
+
+// Search returns the documents that contain every term of query, either
+// exactly or as the prefix of a longer term, ordered by relevance. Terms
+// score their weight in the document times their inverse document
+// frequency.
+func (i *Index) Search(query string) []Hit {
+	terms := Tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var scores map[uint64]float64
+	for _, queryTerm := range terms {
+		matches := make(map[uint64]float64)
+		for _, term := range i.prefixed(queryTerm) {
+			postings := i.postings[term]
+			idf := math.Log(1 + float64(len(i.docs))/float64(len(postings)))
+			if term != queryTerm {
+				idf *= prefixWeight
+			}
+			for id, weight := range postings {
+				matches[id] = max(matches[id], weight*idf)
+			}
+		}
+
+		if scores == nil {
+			scores = matches
+			continue
+		}
+		for id := range scores {
+			if score, ok := matches[id]; ok {
+				scores[id] += score
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+		return hits[a].ID < hits[b].ID
+	})
+	return hits
+}
+
+// prefixed returns the indexed terms that start with prefix.
+func (i *Index) prefixed(prefix string) []string {
+	start := sort.SearchStrings(i.terms, prefix)
+	end := start
+	for end < len(i.terms) && strings.HasPrefix(i.terms[end], prefix) {
+		end++
+	}
+	return i.terms[start:end]
+}
+
+func (i *Index) insertTerm(term string) {
+	n := sort.SearchStrings(i.terms, term)
+	i.terms = append(i.terms, "")
+	copy(i.terms[n+1:], i.terms[n:])
+	i.terms[n] = term
+}
+
+func (i *Index) removeTerm(term string) {
+	n := sort.SearchStrings(i.terms, term)
+	if n < len(i.terms) && i.terms[n] == term {
+		i.terms = append(i.terms[:n], i.terms[n+1:]...)
+	}
+}
+
+// Tokenize splits text into lower case terms of letters and digits.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
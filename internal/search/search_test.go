@@ -0,0 +1,62 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Q3 Planning: Café-Budget, 2024!")
+	want := []string{"q3", "planning", "café", "budget", "2024"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Tokenize() = %v, want %v", got, want)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	index := NewIndex()
+	index.Add(1, Field{Text: "Budget review", Weight: 2}, Field{Text: "Numbers for the board", Weight: 1})
+	index.Add(2, Field{Text: "Lunch", Weight: 2}, Field{Text: "Talk about the budget", Weight: 1})
+	index.Add(3, Field{Text: "Budgeting workshop", Weight: 2})
+
+	ids := func(hits []Hit) []uint64 {
+		result := make([]uint64, 0, len(hits))
+		for _, hit := range hits {
+			result = append(result, hit.ID)
+		}
+		return result
+	}
+
+	tests := []struct {
+		query string
+		want  []uint64
+	}{
+		// Title matches rank above description matches and exact terms
+		// above prefixes, but rare terms weigh more.
+		{"budget", []uint64{1, 3, 2}},
+		{"BUDG", []uint64{3, 1, 2}},
+		{"budget board", []uint64{1}},
+		{"lunch budget", []uint64{2}},
+		{"dinner", []uint64{}},
+		{"  ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := ids(index.Search(tt.query)); !slices.Equal(got, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	index.Add(1, Field{Text: "Offsite", Weight: 2})
+	if got := ids(index.Search("board")); len(got) != 0 {
+		t.Errorf("Replaced document still matches: %v", got)
+	}
+	index.Remove(3)
+	if got := ids(index.Search("budgeting")); len(got) != 0 {
+		t.Errorf("Removed document still matches: %v", got)
+	}
+	if index.Len() != 2 || len(index.terms) != len(index.postings) {
+		t.Errorf("Index has %d documents and %d/%d terms", index.Len(), len(index.terms), len(index.postings))
+	}
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"strconv"
+	"time"
+)
+
+const accessOwner = "owner"
+
+var accessRank = map[string]int{
+	models.AccessFreeBusy: 1,
+	models.AccessRead:     2,
+	models.AccessWrite:    3,
+	accessOwner:           4,
+}
+
+// access returns the level at which actor may use the calendar of owner, or
+// ErrForbidden when it is below need. A nil actor is trusted as the owner.
+func access(actor *uint64, owner uint64, need string) (string, error) {
+	if actor == nil || *actor == owner {
+		return accessOwner, nil
+	}
+	share, err := storage.GetShare(owner, *actor)
+	if errors.Is(err, models.ErrShareNotFound) {
+		return "", models.ErrForbidden
+	}
+	if err != nil {
+		return "", err
+	}
+	if accessRank[share.Level] < accessRank[need] {
+		return "", models.ErrForbidden
+	}
+	return share.Level, nil
+}
+
+// queryAccess returns the level at which the actor of opts may read the
+// calendar of owner.
+func queryAccess(owner uint64, opts []QueryOption) (string, error) {
+	q, err := newEventQuery(opts)
+	if err != nil {
+		return "", err
+	}
+	return access(q.actor, owner, models.AccessFreeBusy)
+}
+
+// readEvents checks access of the query to the calendar of owner and returns
+// the events of get together with the invitations of owner between from and
+// to that match it, as visible to the actor. Tasks due in the range are
+// stored when the query asks for them.
+func readEvents(owner uint64, opts []QueryOption, from, to time.Time, get func() ([]models.Event, error)) ([]models.Event, error) {
+	q, err := newEventQuery(opts)
+	if err != nil {
+		return nil, err
+	}
+	level, err := access(q.actor, owner, models.AccessFreeBusy)
+	if err != nil {
+		return nil, err
+	}
+	if level == models.AccessFreeBusy && q.filtersContent() {
+		return nil, models.ErrForbidden
+	}
+	invited := storage.GetInvitedEvents(owner, from, to)
+	tasks := make([]models.Task, 0)
+	if q.tasks != nil && level != models.AccessFreeBusy {
+		for _, task := range storage.GetTasksDue(owner, from, to) {
+			if q.calendars == nil || q.calendars[task.CalendarID] {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+	events, err := get()
+	// Users with invitations or asking for tasks may have no events of
+	// their own.
+	if errors.Is(err, models.ErrUserNotFound) && (len(invited) > 0 || q.tasks != nil) {
+		events, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if q.tasks != nil {
+		*q.tasks = tasks
+	}
+	// Filters on details do not reveal the events whose details are hidden
+	// from the viewer.
+	revealed := func(event models.Event) bool {
+		return !q.filtersContent() || !hidesDetails(event, level)
+	}
+	result := events[:0]
+	for _, event := range events {
+		if q.match(event) && revealed(event) {
+			result = append(result, event)
+		}
+	}
+	// Invitations are not in a calendar of owner and are listed with the
+	// default one.
+	if q.calendars == nil || q.calendars[models.DefaultCalendarID] {
+		for _, event := range invited {
+			if q.matchContent(event) && revealed(event) && event.Start().Before(to) && event.End().After(from) {
+				result = append(result, event)
+			}
+		}
+	}
+	return visibleEvents(result, level), nil
+}
+
+// Authorize reports whether actor may use the calendar of owner at level.
+func Authorize(actor, owner uint64, level string) error {
+	_, err := access(&actor, owner, level)
+	return err
+}
+
+// visibleEvents returns the events as a viewer at level sees them, see
+// visibleEvent.
+func visibleEvents(events []models.Event, level string) []models.Event {
+	if level == accessOwner {
+		return events
+	}
+	result := make([]models.Event, 0, len(events))
+	for _, event := range events {
+		if visible, ok := visibleEvent(event, level); ok {
+			result = append(result, visible)
+		}
+	}
+	return result
+}
+
+// visibleEvent hides the details of event from a viewer at level. Viewers
+// with free/busy access see busy time only and no free events; other
+// viewers see private events as busy time and confidential ones without
+// their details.
+func visibleEvent(event models.Event, level string) (models.Event, bool) {
+	switch {
+	case level == accessOwner:
+		return event, true
+	case level == models.AccessFreeBusy:
+		if !event.Busy() {
+			return models.Event{}, false
+		}
+		return busyPlaceholder(event), true
+	case event.Visibility == models.VisibilityPrivate:
+		return busyPlaceholder(event), true
+	case event.Visibility == models.VisibilityConfidential:
+		confidential := busyPlaceholder(event)
+		confidential.CalendarID = event.CalendarID
+		confidential.Title = event.Title
+		confidential.Status = event.Status
+		confidential.Visibility = event.Visibility
+		return confidential, true
+	}
+	return event, true
+}
+
+// hidesDetails reports whether a viewer at level sees less than the title,
+// description and labels of event.
+func hidesDetails(event models.Event, level string) bool {
+	if level == accessOwner {
+		return false
+	}
+	return level == models.AccessFreeBusy ||
+		event.Visibility == models.VisibilityPrivate || event.Visibility == models.VisibilityConfidential
+}
+
+// VisibleEvent returns event of the calendar of owner as actor may see it,
+// and false when actor may not see it at all.
+func VisibleEvent(actor, owner uint64, event models.Event) (models.Event, bool) {
+	level, err := access(&actor, owner, models.AccessFreeBusy)
+	if err != nil {
+		return models.Event{}, false
+	}
+	return visibleEvent(event, level)
+}
+
+func busyPlaceholder(event models.Event) models.Event {
+	return models.Event{
+		UserID:       event.UserID,
+		EventID:      event.EventID,
+		Date:         event.Date,
+		Time:         event.Time,
+		Duration:     event.Duration,
+		Title:        "Busy",
+		Transparency: event.Transparency,
+	}
+}
+
+func ShareCalendar(ownerID, granteeID, level string) (*models.Share, error) {
+	oID, err := strconv.ParseUint(ownerID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	gID, err := strconv.ParseUint(granteeID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if oID == gID {
+		return nil, models.ErrInvalidShare
+	}
+	switch level {
+	case models.AccessFreeBusy, models.AccessRead, models.AccessWrite:
+	default:
+		return nil, models.ErrInvalidAccessLevel
+	}
+
+	share := &models.Share{OwnerID: oID, GranteeID: gID, Level: level, CreatedAt: time.Now()}
+	storage.SetShare(share)
+	return share, nil
+}
+
+func UnshareCalendar(ownerID, granteeID string) error {
+	oID, err := strconv.ParseUint(ownerID, 10, 64)
+	if err != nil {
+		return err
+	}
+	gID, err := strconv.ParseUint(granteeID, 10, 64)
+	if err != nil {
+		return err
+	}
+	return storage.DeleteShare(oID, gID)
+}
+
+func GetShares(userID string) (*models.Shares, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	shares := storage.GetShares(uID)
+	return &shares, nil
+}
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"http-calendar/internal/mail"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBookingWindow = 14 * 24 * time.Hour
+	maxNoticeMinutes     = 90 * 24 * 60
+)
+
+// bookingMu serializes bookings so that buffers and daily limits are checked
+// against the appointments booked before.
+var bookingMu sync.Mutex
+
+type AppointmentOption func(*models.AppointmentType) error
+
+// WithAppointmentHours sets the weekly windows of an appointment type in the
+// format of WithWeeklyHours, for example "mon-fri 09:00-12:00".
+func WithAppointmentHours(value, timeZone string) AppointmentOption {
+	return func(appointment *models.AppointmentType) error {
+		availability := models.Availability{TimeZone: appointment.TimeZone, WorkingHours: appointment.Hours}
+		if err := WithWeeklyHours(value, timeZone)(&availability); err != nil {
+			return err
+		}
+		appointment.TimeZone, appointment.Hours = availability.TimeZone, availability.WorkingHours
+		return nil
+	}
+}
+
+// WithAppointmentCalendar books appointments into a calendar of the owner.
+func WithAppointmentCalendar(value string) AppointmentOption {
+	return func(appointment *models.AppointmentType) error {
+		if value == "" {
+			return nil
+		}
+		calendarID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return models.ErrCalendarNotFound
+		}
+		appointment.CalendarID = calendarID
+		return nil
+	}
+}
+
+// WithAppointmentBuffer keeps appointments the given number of minutes away
+// from other events of the owner.
+func WithAppointmentBuffer(value string) AppointmentOption {
+	return appointmentNumber(value, maxBufferMinutes, func(a *models.AppointmentType, n int) { a.Buffer = n })
+}
+
+// WithMinimumNotice requires appointments to be booked the given number of
+// minutes ahead of their start.
+func WithMinimumNotice(value string) AppointmentOption {
+	return appointmentNumber(value, maxNoticeMinutes, func(a *models.AppointmentType, n int) { a.MinimumNotice = n })
+}
+
+// WithDailyLimit caps the number of appointments of the type per day.
+func WithDailyLimit(value string) AppointmentOption {
+	return appointmentNumber(value, maxSlots, func(a *models.AppointmentType, n int) { a.DailyLimit = n })
+}
+
+func appointmentNumber(value string, limit int, set func(*models.AppointmentType, int)) AppointmentOption {
+	return func(appointment *models.AppointmentType) error {
+		if value == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || n > limit {
+			return models.ErrInvalidAppointmentType
+		}
+		set(appointment, n)
+		return nil
+	}
+}
+
+// CreateAppointmentType publishes an appointment type of userID lasting
+// duration minutes. It needs hours, see WithAppointmentHours.
+func CreateAppointmentType(userID, title, description, duration string, opts ...AppointmentOption) (*models.AppointmentType, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if title == "" {
+		return nil, models.ErrTitleIsRequired
+	}
+	minutes, err := strconv.Atoi(duration)
+	if err != nil || minutes <= 0 || minutes > maxDurationMinutes {
+		return nil, models.ErrInvalidDuration
+	}
+
+	buf := make([]byte, 16)
+	if _, err = rand.Read(buf); err != nil {
+		return nil, err
+	}
+	appointment := &models.AppointmentType{
+		AppointmentTypeID: storage.GetNewEventID(),
+		UserID:            uID,
+		Token:             hex.EncodeToString(buf),
+		Title:             title,
+		Description:       description,
+		Duration:          minutes,
+	}
+	for _, opt := range opts {
+		if err = opt(appointment); err != nil {
+			return nil, err
+		}
+	}
+	if len(appointment.Hours) == 0 {
+		return nil, models.ErrInvalidWorkingHours
+	}
+	if calendar, err := storage.GetCalendar(uID, appointment.CalendarID); err == nil && appointment.TimeZone == "" {
+		// Hours without a zone of their own follow the calendar.
+		appointment.TimeZone = calendar.TimeZone
+	}
+
+	if err = storage.CreateAppointmentType(appointment); err != nil {
+		return nil, err
+	}
+	return appointment, nil
+}
+
+func DeleteAppointmentType(userID, appointmentTypeID string) error {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return err
+	}
+	aID, err := strconv.ParseUint(appointmentTypeID, 10, 64)
+	if err != nil {
+		return models.ErrAppointmentTypeNotFound
+	}
+	return storage.DeleteAppointmentType(uID, aID)
+}
+
+func GetAppointmentTypes(userID string) ([]models.AppointmentType, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return storage.GetAppointmentTypes(uID), nil
+}
+
+// GetBookingPage returns the open slots of the appointment type published
+// under token between from and to, by default the next 14 days. It reveals
+// nothing about the events of the owner.
+func GetBookingPage(token, from, to string) (*models.BookingPage, error) {
+	appointment, err := storage.GetAppointmentTypeByToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := time.Now().UTC().Truncate(time.Minute), time.Time{}
+	if from != "" || to != "" {
+		if start, end, err = parseRange(from, to); err != nil {
+			return nil, err
+		}
+		if end.Sub(start) > maxSlotWindow {
+			return nil, models.ErrInvalidRange
+		}
+	} else {
+		end = start.Add(defaultBookingWindow)
+	}
+
+	return &models.BookingPage{
+		Title:       appointment.Title,
+		Description: appointment.Description,
+		Duration:    appointment.Duration,
+		TimeZone:    appointment.TimeZone,
+		Slots:       newAppointmentSchedule(appointment, start, end).slots(start, end),
+	}, nil
+}
+
+// BookAppointment books the slot of the appointment type published under
+// token that begins at start, an RFC 3339 timestamp, for the person with
+// name and email. The appointment is created as an event of the owner with
+// the person as an accepted attendee, who is sent an invitation when mail is
+// enabled.
+func BookAppointment(token, start, name, email, note string) (*models.Event, error) {
+	appointment, err := storage.GetAppointmentTypeByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	begin, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return nil, models.ErrInvalidTime
+	}
+	begin = begin.UTC()
+	attendee, err := parseAttendee(email)
+	if err != nil || attendee.Email == "" {
+		return nil, models.ErrInvalidAttendee
+	}
+	if name == "" {
+		name = attendee.Email
+	}
+
+	bookingMu.Lock()
+	defer bookingMu.Unlock()
+
+	length := time.Duration(appointment.Duration) * time.Minute
+	day := startOfDay(begin.In(appointment.Location()))
+	if !newAppointmentSchedule(appointment, day, day.AddDate(0, 0, 1)).open(begin, length) {
+		return nil, models.ErrSlotUnavailable
+	}
+
+	// The conflict check repeats the overlap test under the storage lock, so
+	// events the owner creates meanwhile are not double booked. The event is
+	// read in the zone of its calendar.
+	local := begin.In(calendarLocation(appointment.UserID, appointment.CalendarID))
+	event, err := CreateEvent(strconv.FormatUint(appointment.UserID, 10), local.Format(DateFormat),
+		appointment.Title+": "+name, note,
+		WithTime(local.Format(models.TimeFormat)),
+		WithDuration(strconv.Itoa(appointment.Duration)),
+		InCalendar(strconv.FormatUint(appointment.CalendarID, 10)),
+		WithAttendees(attendee.Email),
+		WithConflictMode(models.ConflictReject, nil),
+		bookedThrough(appointment),
+	)
+	if errors.Is(err, models.ErrConflict) {
+		return nil, models.ErrSlotUnavailable
+	}
+	if err != nil {
+		return nil, err
+	}
+	confirmAppointment(*event, attendee.Email)
+	return event, nil
+}
+
+// bookedThrough marks the event as an appointment of the type accepted by
+// its attendees.
+func bookedThrough(appointment models.AppointmentType) EventOption {
+	return eventOptionFunc(func(r *eventRequest) error {
+		r.event.AppointmentTypeID = appointment.AppointmentTypeID
+		for i := range r.event.Attendees {
+			r.event.Attendees[i].Status = models.StatusAccepted
+		}
+		return nil
+	})
+}
+
+// confirmAppointment emails the invitation to the person who booked in the
+// background; the appointment stays booked when the mail fails.
+func confirmAppointment(event models.Event, to string) {
+	if sender == nil {
+		return
+	}
+	organizer, _ := UserEmail(event.UserID)
+	msg, err := mail.InvitationMessage(to, organizer, event)
+	if err != nil {
+		log.Printf("Failed build confirmation of event %d: %v\n", event.EventID, err)
+		return
+	}
+	s := sender
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), replyTimeout)
+		defer cancel()
+		if err := s.Send(ctx, msg); err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("Failed send confirmation of event %d to %s: %v\n", event.EventID, to, err)
+		}
+	}()
+}
+
+// appointmentSchedule decides which slots of an appointment type are open
+// within the range it was built for.
+type appointmentSchedule struct {
+	appointment models.AppointmentType
+	hours       models.Availability
+	// busy holds the busy time of the owner widened by the buffer, and the
+	// time the owner is unavailable.
+	busy     []models.Interval
+	booked   map[string]int
+	earliest time.Time
+}
+
+func newAppointmentSchedule(appointment models.AppointmentType, from, to time.Time) *appointmentSchedule {
+	s := &appointmentSchedule{
+		appointment: appointment,
+		hours:       models.Availability{TimeZone: appointment.TimeZone, WorkingHours: appointment.Hours},
+		booked:      make(map[string]int),
+		earliest:    time.Now().Add(time.Duration(appointment.MinimumNotice) * time.Minute),
+	}
+
+	buffer := time.Duration(appointment.Buffer) * time.Minute
+	var busy []models.Interval
+	for _, interval := range busyIntervals(appointment.UserID, from.Add(-buffer), to.Add(buffer)) {
+		busy = append(busy, models.Interval{Start: interval.Start.Add(-buffer), End: interval.End.Add(buffer)})
+	}
+	busy = append(busy, unavailableIntervals(appointment.UserID, from, to)...)
+	s.busy = mergeIntervals(busy)
+
+	if appointment.DailyLimit > 0 {
+		loc := appointment.Location()
+		first := startOfDay(from.In(loc))
+		for _, event := range storage.GetAppointments(appointment, first, to.AddDate(0, 0, 1)) {
+			if takesPlace(event) {
+				s.booked[event.Start().In(loc).Format(DateFormat)]++
+			}
+		}
+	}
+	return s
+}
+
+// takesPlace reports whether a booked appointment still counts toward the
+// daily limit: it was not cancelled and not declined by all its attendees.
+func takesPlace(event models.Event) bool {
+	if event.Status == models.EventCancelled {
+		return false
+	}
+	for _, attendee := range event.Attendees {
+		if attendee.Status != models.StatusDeclined {
+			return true
+		}
+	}
+	return len(event.Attendees) == 0
+}
+
+// open reports whether an appointment of length can begin at start, which
+// has to be a quarter hour step from the beginning of a window.
+func (s *appointmentSchedule) open(start time.Time, length time.Duration) bool {
+	end := start.Add(length)
+	if start.Before(s.earliest) || overlaps(s.busy, start, end) {
+		return false
+	}
+	day := startOfDay(start.In(s.appointment.Location()))
+	if s.appointment.DailyLimit > 0 && s.booked[day.Format(DateFormat)] >= s.appointment.DailyLimit {
+		return false
+	}
+	for _, window := range workingWindows(s.hours, day) {
+		if !start.Before(window.Start) && !end.After(window.End) && start.Sub(window.Start)%slotStep == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// slots returns the open slots within [from, to) in order. They start at
+// quarter hours from the beginning of each window and may overlap, so the
+// person booking can choose any of them.
+func (s *appointmentSchedule) slots(from, to time.Time) []models.Interval {
+	length := time.Duration(s.appointment.Duration) * time.Minute
+	result := make([]models.Interval, 0)
+	for day := startOfDay(from.In(s.appointment.Location())); day.Before(to); day = day.AddDate(0, 0, 1) {
+		for _, window := range workingWindows(s.hours, day) {
+			for start := window.Start; !start.Add(length).After(window.End); start = start.Add(slotStep) {
+				if start.Before(from) || start.Add(length).After(to) || !s.open(start, length) {
+					continue
+				}
+				if n := len(result); n > 0 && !start.After(result[n-1].Start) {
+					continue
+				}
+				result = append(result, models.Interval{Start: start.UTC(), End: start.Add(length).UTC()})
+			}
+		}
+	}
+	return result
+}
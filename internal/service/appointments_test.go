@@ -0,0 +1,129 @@
+package service
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBookingPage(t *testing.T) {
+	storage.Clear()
+
+	// 2030-01-14 is a Monday.
+	if _, err := CreateEvent("1", "2030-01-14", "Standup", "", WithTime("10:00")); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	intro, err := CreateAppointmentType("1", "Intro", "", "30",
+		WithAppointmentHours("mon-fri 09:00-12:00", ""),
+		WithAppointmentBuffer("15"),
+		WithDailyLimit("2"),
+	)
+	if err != nil {
+		t.Fatalf("CreateAppointmentType() error = %v", err)
+	}
+
+	starts := func(date string) []string {
+		t.Helper()
+		page, err := GetBookingPage(intro.Token, date, date+"T23:59:00Z")
+		if err != nil {
+			t.Fatalf("GetBookingPage() error = %v", err)
+		}
+		result := make([]string, 0, len(page.Slots))
+		for _, slot := range page.Slots {
+			result = append(result, slot.Start.Format(models.TimeFormat))
+		}
+		return result
+	}
+
+	assertStarts(t, starts("2030-01-14"), "09:00", "09:15", "11:15", "11:30")
+
+	event, err := BookAppointment(intro.Token, "2030-01-14T09:00:00Z", "Jane", "jane@example.com", "Hello")
+	if err != nil {
+		t.Fatalf("BookAppointment() error = %v", err)
+	}
+	if event.Title != "Intro: Jane" || event.Duration != 30 || event.AppointmentTypeID != intro.AppointmentTypeID {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	if len(event.Attendees) != 1 || event.Attendees[0].Status != models.StatusAccepted {
+		t.Errorf("Unexpected attendees %+v", event.Attendees)
+	}
+	if _, err = BookAppointment(intro.Token, "2030-01-14T09:00:00Z", "", "joe@example.com", ""); !errors.Is(err, models.ErrSlotUnavailable) {
+		t.Errorf("Double booking error = %v, want %v", err, models.ErrSlotUnavailable)
+	}
+	assertStarts(t, starts("2030-01-14"), "11:15", "11:30")
+
+	if _, err = BookAppointment(intro.Token, "2030-01-14T11:30:00Z", "", "joe@example.com", ""); err != nil {
+		t.Fatalf("BookAppointment() error = %v", err)
+	}
+	assertStarts(t, starts("2030-01-14"))
+
+	// A cancelled appointment frees its place of the daily limit.
+	if _, err = UpdateEvent("1", strconv.FormatUint(event.EventID, 10), "2030-01-14", event.Title, "", WithStatus(models.EventCancelled)); err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	assertStarts(t, starts("2030-01-14"), "09:00", "09:15")
+	if _, err = BookAppointment(intro.Token, "2030-01-14T09:15:00Z", "", "ann@example.com", ""); err != nil {
+		t.Fatalf("BookAppointment() error = %v", err)
+	}
+	assertStarts(t, starts("2030-01-14"))
+	if got := starts("2030-01-15"); len(got) != 11 {
+		t.Errorf("Expected 11 slots on Tuesday, got %v", got)
+	}
+	assertStarts(t, starts("2030-01-19"))
+
+	for _, tt := range []struct {
+		name, start, email string
+		want               error
+	}{
+		{"in the past", "2024-01-15T09:00:00Z", "jane@example.com", models.ErrSlotUnavailable},
+		{"outside the hours", "2030-01-15T12:00:00Z", "jane@example.com", models.ErrSlotUnavailable},
+		{"off the quarter hour", "2030-01-15T09:05:00Z", "jane@example.com", models.ErrSlotUnavailable},
+		{"without email", "2030-01-15T09:00:00Z", "", models.ErrInvalidAttendee},
+		{"invalid start", "tomorrow", "jane@example.com", models.ErrInvalidTime},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := BookAppointment(intro.Token, tt.start, "", tt.email, ""); !errors.Is(err, tt.want) {
+				t.Errorf("BookAppointment() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err = GetBookingPage("unknown", "", ""); !errors.Is(err, models.ErrAppointmentTypeNotFound) {
+		t.Errorf("GetBookingPage() error = %v, want %v", err, models.ErrAppointmentTypeNotFound)
+	}
+}
+
+func TestBookingPage_MinimumNotice(t *testing.T) {
+	storage.Clear()
+
+	anytime, err := CreateAppointmentType("1", "Call", "", "60",
+		WithAppointmentHours("mon-sun 00:00-24:00", ""),
+		WithMinimumNotice("1440"),
+	)
+	if err != nil {
+		t.Fatalf("CreateAppointmentType() error = %v", err)
+	}
+
+	page, err := GetBookingPage(anytime.Token, "", "")
+	if err != nil {
+		t.Fatalf("GetBookingPage() error = %v", err)
+	}
+	if len(page.Slots) == 0 || page.Slots[0].Start.Before(time.Now().Add(24*time.Hour)) {
+		t.Errorf("First slot does not respect the notice: %+v", page.Slots[:min(len(page.Slots), 1)])
+	}
+
+	if _, err = CreateAppointmentType("1", "Call", "", "60"); !errors.Is(err, models.ErrInvalidWorkingHours) {
+		t.Errorf("CreateAppointmentType() without hours error = %v, want %v", err, models.ErrInvalidWorkingHours)
+	}
+}
+
+func assertStarts(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if !slices.Equal(got, want) {
+		t.Errorf("Slots start at %v, want %v", got, want)
+	}
+}
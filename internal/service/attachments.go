@@ -0,0 +1,202 @@
+package service
+
+import (
+	"errors"
+	"http-calendar/internal/blob"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"http-calendar/internal/webhook"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxAttachments          = 20
+	maxAttachmentNameLength = 255
+)
+
+// blobMu orders adding blobs to the store against removing the ones no
+// event is attached to, so that content uploaded again is not lost.
+var blobMu sync.Mutex
+
+// AddAttachment stores content as a file called name and attaches it to
+// an event of userID. Content is read as it arrives and must not exceed
+// the size limit of the store; its type is sniffed. Attaching needs write
+// access.
+func AddAttachment(userID, eventID, name string, content io.Reader, opts ...EventOption) (*models.Attachment, error) {
+	uID, eID, err := parseEventKey(userID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkWrite(uID, opts); err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(filepath.Base(filepath.Clean("/" + name)))
+	if name == "" || name == "/" || utf8.RuneCountInString(name) > maxAttachmentNameLength {
+		return nil, models.ErrInvalidAttachment
+	}
+	// Fail before reading the content when the event does not exist.
+	if _, err = storage.GetEvent(uID, eID); err != nil {
+		return nil, err
+	}
+
+	upload, err := blob.Write(content, name)
+	if err != nil {
+		return nil, blobError(err)
+	}
+	defer upload.Discard()
+
+	attachment := models.Attachment{
+		AttachmentID: storage.GetNewEventID(),
+		Name:         name,
+		ContentType:  upload.ContentType,
+		Size:         upload.Size,
+		Hash:         upload.Hash,
+		UploadedAt:   time.Now().UTC(),
+	}
+
+	blobMu.Lock()
+	defer blobMu.Unlock()
+	if err = upload.Commit(); err != nil {
+		return nil, err
+	}
+	event, err := storage.UpdateAttachments(uID, eID, func(attachments []models.Attachment) ([]models.Attachment, error) {
+		if len(attachments) >= maxAttachments {
+			return nil, models.ErrTooManyAttachments
+		}
+		return append(slices.Clone(attachments), attachment), nil
+	})
+	if err != nil {
+		collectBlobs([]models.Attachment{attachment})
+		return nil, err
+	}
+	webhook.Notify(models.ChangeUpdated, event)
+	return &attachment, nil
+}
+
+// DeleteAttachment detaches a file from an event of userID. Its content is
+// removed when no other event has it attached.
+func DeleteAttachment(userID, eventID, attachmentID string, opts ...EventOption) error {
+	uID, eID, err := parseEventKey(userID, eventID)
+	if err != nil {
+		return err
+	}
+	aID, err := strconv.ParseUint(attachmentID, 10, 64)
+	if err != nil {
+		return models.ErrAttachmentNotFound
+	}
+	if err = checkWrite(uID, opts); err != nil {
+		return err
+	}
+
+	var removed models.Attachment
+	event, err := storage.UpdateAttachments(uID, eID, func(attachments []models.Attachment) ([]models.Attachment, error) {
+		i := slices.IndexFunc(attachments, func(a models.Attachment) bool { return a.AttachmentID == aID })
+		if i < 0 {
+			return nil, models.ErrAttachmentNotFound
+		}
+		removed = attachments[i]
+		return slices.Delete(slices.Clone(attachments), i, i+1), nil
+	})
+	if err != nil {
+		return err
+	}
+	releaseBlobs([]models.Attachment{removed})
+	webhook.Notify(models.ChangeUpdated, event)
+	return nil
+}
+
+// OpenAttachment returns a file attached to an event of userID with its
+// content, which the caller has to close. Reading attachments needs read
+// access to an event whose details the actor sees.
+func OpenAttachment(userID, eventID, attachmentID string, opts ...QueryOption) (*models.Attachment, *os.File, error) {
+	uID, eID, err := parseEventKey(userID, eventID)
+	if err != nil {
+		return nil, nil, err
+	}
+	aID, err := strconv.ParseUint(attachmentID, 10, 64)
+	if err != nil {
+		return nil, nil, models.ErrAttachmentNotFound
+	}
+	q, err := newEventQuery(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	level, err := access(q.actor, uID, models.AccessRead)
+	if err != nil {
+		return nil, nil, err
+	}
+	event, err := storage.GetEvent(uID, eID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hidesDetails(event, level) {
+		return nil, nil, models.ErrForbidden
+	}
+	i := slices.IndexFunc(event.Attachments, func(a models.Attachment) bool { return a.AttachmentID == aID })
+	if i < 0 {
+		return nil, nil, models.ErrAttachmentNotFound
+	}
+	file, err := blob.Open(event.Attachments[i].Hash)
+	if err != nil {
+		return nil, nil, blobError(err)
+	}
+	return &event.Attachments[i], file, nil
+}
+
+// releaseBlobs removes the content of attachments that no event has
+// attached anymore.
+func releaseBlobs(attachments []models.Attachment) {
+	if len(attachments) == 0 {
+		return
+	}
+	blobMu.Lock()
+	defer blobMu.Unlock()
+	collectBlobs(attachments)
+}
+
+// collectBlobs is releaseBlobs for callers holding blobMu.
+func collectBlobs(attachments []models.Attachment) {
+	for _, attachment := range attachments {
+		if storage.BlobInUse(attachment.Hash) {
+			continue
+		}
+		if err := blob.Delete(attachment.Hash); err != nil {
+			log.Printf("Failed delete blob %s: %v\n", attachment.Hash, err)
+		}
+	}
+}
+
+func blobError(err error) error {
+	switch {
+	case errors.Is(err, blob.ErrDisabled):
+		return models.ErrAttachmentsNotEnabled
+	case errors.Is(err, blob.ErrTooLarge):
+		return models.ErrAttachmentTooLarge
+	case errors.Is(err, blob.ErrEmpty):
+		return models.ErrInvalidAttachment
+	case errors.Is(err, blob.ErrNotFound):
+		return models.ErrAttachmentNotFound
+	}
+	return err
+}
+
+func parseEventKey(userID, eventID string) (uint64, uint64, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	eID, err := strconv.ParseUint(eventID, 10, 64)
+	if err != nil {
+		return 0, 0, models.ErrEventNotFound
+	}
+	return uID, eID, nil
+}
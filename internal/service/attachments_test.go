@@ -0,0 +1,142 @@
+package service
+
+import (
+	"errors"
+	"http-calendar/internal/blob"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAttachments(t *testing.T) {
+	storage.Clear()
+	if err := blob.Init(t.TempDir(), 1<<10); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	t.Cleanup(func() { _ = blob.Init("", 0) })
+
+	review, err := CreateEvent("1", "2024-01-15", "Review", "")
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	retro, err := CreateEvent("1", "2024-01-16", "Retro", "")
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	reviewID, retroID := strconv.FormatUint(review.EventID, 10), strconv.FormatUint(retro.EventID, 10)
+
+	agenda, err := AddAttachment("1", reviewID, "../agenda.pdf", strings.NewReader("%PDF-1.7 agenda"))
+	if err != nil {
+		t.Fatalf("AddAttachment() error = %v", err)
+	}
+	if agenda.Name != "agenda.pdf" || agenda.ContentType != "application/pdf" || agenda.Size != 15 {
+		t.Errorf("Attachment = %+v", agenda)
+	}
+	shared, err := AddAttachment("1", retroID, "agenda.pdf", strings.NewReader("%PDF-1.7 agenda"))
+	if err != nil {
+		t.Fatalf("AddAttachment() error = %v", err)
+	}
+
+	updated, err := UpdateEvent("1", reviewID, "2024-01-15", "Quarterly review", "")
+	if err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if len(updated.Attachments) != 1 {
+		t.Errorf("Update drops attachments: %+v", updated.Attachments)
+	}
+
+	if _, err = ShareCalendar("1", "2", models.AccessRead); err != nil {
+		t.Fatalf("ShareCalendar() error = %v", err)
+	}
+	if _, err = AddAttachment("1", reviewID, "notes.txt", strings.NewReader("notes"), Actor(2)); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("Read viewer upload error = %v, want %v", err, models.ErrForbidden)
+	}
+	attachmentID := strconv.FormatUint(agenda.AttachmentID, 10)
+	_, file, err := OpenAttachment("1", reviewID, attachmentID, Actor(2))
+	if err != nil {
+		t.Fatalf("OpenAttachment() error = %v", err)
+	}
+	data, _ := io.ReadAll(file)
+	file.Close()
+	if string(data) != "%PDF-1.7 agenda" {
+		t.Errorf("Content = %q", data)
+	}
+
+	if _, err = AddAttachment("1", reviewID, "big.txt", strings.NewReader(strings.Repeat("x", 2<<10))); !errors.Is(err, models.ErrAttachmentTooLarge) {
+		t.Errorf("Large upload error = %v, want %v", err, models.ErrAttachmentTooLarge)
+	}
+
+	// The content stays while another event has it attached.
+	if err = DeleteEvent("1", reviewID); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+	_, file, err = OpenAttachment("1", retroID, strconv.FormatUint(shared.AttachmentID, 10))
+	if err != nil {
+		t.Fatalf("OpenAttachment() of shared content error = %v", err)
+	}
+	file.Close()
+
+	if err = DeleteAttachment("1", retroID, strconv.FormatUint(shared.AttachmentID, 10)); err != nil {
+		t.Fatalf("DeleteAttachment() error = %v", err)
+	}
+	// The deleted review keeps the content in the trash until it is purged.
+	if _, err = blob.Open(shared.Hash); err != nil {
+		t.Errorf("Content of trashed event removed, error = %v", err)
+	}
+	if n := PurgeTrash(time.Now().Add(trashRetention + time.Minute)); n != 1 {
+		t.Errorf("PurgeTrash() = %d, want 1", n)
+	}
+	if _, err = blob.Open(shared.Hash); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("Content of deleted attachments kept, error = %v", err)
+	}
+	if err = DeleteAttachment("1", retroID, strconv.FormatUint(shared.AttachmentID, 10)); !errors.Is(err, models.ErrAttachmentNotFound) {
+		t.Errorf("DeleteAttachment() twice error = %v, want %v", err, models.ErrAttachmentNotFound)
+	}
+}
+
+func TestAttachmentsKeptByConcurrentUpdates(t *testing.T) {
+	storage.Clear()
+	if err := blob.Init(t.TempDir(), 1<<10); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	t.Cleanup(func() { _ = blob.Init("", 0) })
+
+	event, err := CreateEvent("1", "2024-01-15", "Review", "", WithTime("10:00"), WithAlarms("15"))
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	eventID := strconv.FormatUint(event.EventID, 10)
+
+	const uploads = 10
+	var wg sync.WaitGroup
+	for i := range uploads {
+		wg.Go(func() {
+			if _, err := AddAttachment("1", eventID, "notes.txt", strings.NewReader("notes "+strconv.Itoa(i))); err != nil {
+				t.Errorf("AddAttachment() error = %v", err)
+			}
+		})
+		wg.Go(func() {
+			_, err := UpdateEvent("1", eventID, "2024-01-15", "Review "+strconv.Itoa(i), "")
+			if err != nil && !errors.Is(err, models.ErrEventChanged) {
+				t.Errorf("UpdateEvent() error = %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	stored, err := storage.GetEvent(1, event.EventID)
+	if err != nil {
+		t.Fatalf("GetEvent() error = %v", err)
+	}
+	if len(stored.Attachments) != uploads {
+		t.Errorf("Expected %d attachments, got %d", uploads, len(stored.Attachments))
+	}
+	if stored.Time != "10:00" || len(stored.Alarms) != 1 {
+		t.Errorf("Update should keep time and alarms: %+v", stored)
+	}
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type AvailabilityOption func(*models.Availability) error
+
+// WithWeeklyHours sets the working hours from a comma separated list of
+// weekday windows in the time zone timeZone, for example
+// "mon-fri 09:00-17:00,sat 10:00-12:00". Value "off" removes them.
+func WithWeeklyHours(value, timeZone string) AvailabilityOption {
+	return func(availability *models.Availability) error {
+		if timeZone != "" {
+			if _, err := time.LoadLocation(timeZone); err != nil {
+				return models.ErrInvalidTimeZone
+			}
+			availability.TimeZone = timeZone
+		}
+		switch value {
+		case "":
+			return nil
+		case "off":
+			availability.WorkingHours = nil
+			return nil
+		}
+
+		var hours []models.WorkingHours
+		for _, part := range strings.Split(value, ",") {
+			days, window, ok := strings.Cut(strings.TrimSpace(part), " ")
+			if !ok {
+				return models.ErrInvalidWorkingHours
+			}
+			start, end, ok := strings.Cut(strings.TrimSpace(window), "-")
+			if !ok || !validWindow(start, end) {
+				return models.ErrInvalidWorkingHours
+			}
+			weekdays, err := parseWeekdays(days)
+			if err != nil {
+				return err
+			}
+			for _, day := range weekdays {
+				hours = append(hours, models.WorkingHours{Day: dayName(day), Start: start, End: end})
+			}
+		}
+		availability.WorkingHours = hours
+		return nil
+	}
+}
+
+// WithAutoDecline turns declining of invitations during out-of-office on
+// ("true") or off ("false").
+func WithAutoDecline(value string) AvailabilityOption {
+	return func(availability *models.Availability) error {
+		if value == "" {
+			return nil
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return models.ErrInvalidAutoDecline
+		}
+		availability.AutoDecline = enabled
+		return nil
+	}
+}
+
+func validWindow(start, end string) bool {
+	from, ok := parseClock(start)
+	if !ok {
+		return false
+	}
+	to, ok := parseClock(end)
+	return ok && to > from
+}
+
+// parseClock returns the offset of an HH:MM clock from midnight. "24:00" is
+// the end of the day.
+func parseClock(value string) (time.Duration, bool) {
+	if value == "24:00" {
+		return 24 * time.Hour, true
+	}
+	t, err := time.Parse(models.TimeFormat, value)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, true
+}
+
+// parseWeekdays accepts a day such as "mon" or a range such as "mon-fri".
+func parseWeekdays(value string) ([]time.Weekday, error) {
+	first, last, isRange := strings.Cut(value, "-")
+	from, ok := parseWeekday(first)
+	if !ok {
+		return nil, models.ErrInvalidWorkingHours
+	}
+	to := from
+	if isRange {
+		if to, ok = parseWeekday(last); !ok {
+			return nil, models.ErrInvalidWorkingHours
+		}
+	}
+	days := []time.Weekday{from}
+	for day := from; day != to; {
+		day = (day + 1) % 7
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+func dayName(day time.Weekday) string {
+	return strings.ToLower(day.String()[:3])
+}
+
+func UpdateAvailability(userID string, opts ...AvailabilityOption) (*models.Availability, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	availability, err := storage.UpdateAvailability(uID, func(availability *models.Availability) error {
+		for _, opt := range opts {
+			if err := opt(availability); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &availability, nil
+}
+
+func GetAvailability(userID string) (*models.Availability, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	availability := storage.GetAvailability(uID)
+	return &availability, nil
+}
+
+// AddOutOfOffice marks userID as away between from and to, which are dates
+// or RFC 3339 timestamps.
+func AddOutOfOffice(userID, from, to, note string) (*models.OutOfOffice, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	start, err := ParseTime(from)
+	if err != nil {
+		return nil, models.ErrInvalidRange
+	}
+	end, err := ParseTime(to)
+	if err != nil || !end.After(start) {
+		return nil, models.ErrInvalidRange
+	}
+
+	ooo := models.OutOfOffice{ID: storage.GetNewEventID(), Start: start, End: end, Note: note}
+	_, err = storage.UpdateAvailability(uID, func(availability *models.Availability) error {
+		availability.OutOfOffice = append(availability.OutOfOffice, ooo)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ooo, nil
+}
+
+func DeleteOutOfOffice(userID, id string) error {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return err
+	}
+	oID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	_, err = storage.UpdateAvailability(uID, func(availability *models.Availability) error {
+		i := slices.IndexFunc(availability.OutOfOffice, func(ooo models.OutOfOffice) bool { return ooo.ID == oID })
+		if i < 0 {
+			return models.ErrOutOfOfficeNotFound
+		}
+		availability.OutOfOffice = slices.Delete(availability.OutOfOffice, i, i+1)
+		return nil
+	})
+	return err
+}
+
+// unavailableIntervals returns the sorted, disjoint intervals within
+// [from, to) in which userID is out of office or outside working hours.
+func unavailableIntervals(userID uint64, from, to time.Time) []models.Interval {
+	availability := storage.GetAvailability(userID)
+
+	var intervals []models.Interval
+	for _, ooo := range availability.OutOfOffice {
+		if ooo.Start.Before(to) && ooo.End.After(from) {
+			intervals = append(intervals, models.Interval{Start: maxTime(ooo.Start, from), End: minTime(ooo.End, to)})
+		}
+	}
+
+	if len(availability.WorkingHours) > 0 {
+		loc := availability.Location()
+		// Walk the days that touch the range and collect the time between
+		// the working windows.
+		cursor := from
+		for day := startOfDay(from.In(loc)).AddDate(0, 0, -1); day.Before(to); day = day.AddDate(0, 0, 1) {
+			for _, window := range workingWindows(availability, day) {
+				if window.Start.After(cursor) {
+					intervals = append(intervals, models.Interval{Start: cursor, End: minTime(window.Start, to)})
+				}
+				cursor = maxTime(cursor, window.End)
+			}
+		}
+		if cursor.Before(to) {
+			intervals = append(intervals, models.Interval{Start: cursor, End: to})
+		}
+	}
+
+	result := make([]models.Interval, 0, len(intervals))
+	for _, interval := range intervals {
+		if interval.End.After(interval.Start) {
+			result = append(result, interval)
+		}
+	}
+	return mergeIntervals(result)
+}
+
+// workingWindows returns the working hours on day, ordered by start.
+func workingWindows(availability models.Availability, day time.Time) []models.Interval {
+	var windows []models.Interval
+	for _, hours := range availability.WorkingHours {
+		if hours.Day != dayName(day.Weekday()) {
+			continue
+		}
+		windows = append(windows, models.Interval{Start: atClock(day, hours.Start), End: atClock(day, hours.End)})
+	}
+	slices.SortFunc(windows, func(a, b models.Interval) int { return a.Start.Compare(b.Start) })
+	return windows
+}
+
+// atClock returns the moment of the HH:MM clock on day.
+func atClock(day time.Time, clock string) time.Time {
+	offset, _ := parseClock(clock)
+	if offset == 24*time.Hour {
+		return day.AddDate(0, 0, 1)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, day.Location())
+}
+
+// autoDecline declines invitations of event for attendees that asked for it
+// and are out of office when it takes place.
+func autoDecline(event *models.Event) {
+	event.Attendees = slices.Clone(event.Attendees)
+	for i, attendee := range event.Attendees {
+		if attendee.UserID == 0 || attendee.Status != models.StatusNeedsAction {
+			continue
+		}
+		availability := storage.GetAvailability(attendee.UserID)
+		if !availability.AutoDecline {
+			continue
+		}
+		for _, ooo := range availability.OutOfOffice {
+			if ooo.Start.Before(event.End()) && ooo.End.After(event.Start()) {
+				event.Attendees[i].Status = models.StatusDeclined
+				break
+			}
+		}
+	}
+}
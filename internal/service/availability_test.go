@@ -0,0 +1,147 @@
+package service
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithWeeklyHours(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr error
+	}{
+		{name: "weekdays", value: "mon-fri 09:00-17:00", want: 5},
+		{name: "split day", value: "mon 09:00-12:00, mon 13:00-17:00", want: 2},
+		{name: "wrapping range", value: "fri-mon 10:00-24:00", want: 4},
+		{name: "off", value: "off", want: 0},
+		{name: "unknown day", value: "someday 09:00-17:00", wantErr: models.ErrInvalidWorkingHours},
+		{name: "end before start", value: "mon 17:00-09:00", wantErr: models.ErrInvalidWorkingHours},
+		{name: "missing window", value: "mon", wantErr: models.ErrInvalidWorkingHours},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			availability := models.Availability{WorkingHours: []models.WorkingHours{{Day: "sun"}}}
+			err := WithWeeklyHours(tt.value, "")(&availability)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WithWeeklyHours() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && len(availability.WorkingHours) != tt.want {
+				t.Errorf("Got %d windows, want %d: %+v", len(availability.WorkingHours), tt.want, availability.WorkingHours)
+			}
+		})
+	}
+
+	if err := WithWeeklyHours("", "Mars/Olympus")(&models.Availability{}); !errors.Is(err, models.ErrInvalidTimeZone) {
+		t.Errorf("WithWeeklyHours() error = %v, want %v", err, models.ErrInvalidTimeZone)
+	}
+}
+
+func TestAvailability(t *testing.T) {
+	storage.Clear()
+
+	// 09:00-17:00 in New York is 14:00-22:00 UTC in January.
+	_, err := UpdateAvailability("1", WithWeeklyHours("mon-fri 09:00-17:00", "America/New_York"), WithAutoDecline("true"))
+	if err != nil {
+		t.Fatalf("UpdateAvailability() error = %v", err)
+	}
+	ooo, err := AddOutOfOffice("1", "2024-01-17", "2024-01-18", "Dentist")
+	if err != nil {
+		t.Fatalf("AddOutOfOffice() error = %v", err)
+	}
+
+	result, err := GetFreeBusy("1", "2024-01-15", "2024-01-16")
+	if err != nil {
+		t.Fatalf("GetFreeBusy() error = %v", err)
+	}
+	at := func(day, hour int) time.Time { return time.Date(2024, 1, day, hour, 0, 0, 0, time.UTC) }
+	unavailable := result.Users[0].Unavailable
+	if len(unavailable) != 2 || !unavailable[0].End.Equal(at(15, 14)) || !unavailable[1].Start.Equal(at(15, 22)) {
+		t.Errorf("Unexpected unavailable time %+v", unavailable)
+	}
+
+	slots, err := FindSlots("1", "60", "2024-01-15", "2024-01-19", WithWorkingHours("00:00", "24:00"), WithLimit("1"))
+	if err != nil {
+		t.Fatalf("FindSlots() error = %v", err)
+	}
+	if len(slots) != 1 || !slots[0].Start.Equal(at(15, 14)) {
+		t.Errorf("Slots should respect the working hours of the participant: %+v", slots)
+	}
+	slots, err = FindSlots("1", "60", "2024-01-17", "2024-01-18", WithWorkingHours("00:00", "24:00"))
+	if err != nil {
+		t.Fatalf("FindSlots() error = %v", err)
+	}
+	if len(slots) != 0 {
+		t.Errorf("Slots found during out-of-office: %+v", slots)
+	}
+
+	during, err := CreateEvent("2", "2024-01-17", "Planning", "", WithTime("15:00"), WithAttendees("1,3"))
+	if err != nil {
+		t.Fatalf("Failed to create test event: %v", err)
+	}
+	if during.Attendees[0].Status != models.StatusDeclined || during.Attendees[1].Status != models.StatusNeedsAction {
+		t.Errorf("Expected only the absent attendee to decline: %+v", during.Attendees)
+	}
+	after, err := CreateEvent("2", "2024-01-18", "Planning", "", WithTime("15:00"), WithAttendees("1"))
+	if err != nil {
+		t.Fatalf("Failed to create test event: %v", err)
+	}
+	if after.Attendees[0].Status != models.StatusNeedsAction {
+		t.Errorf("Invitation outside out-of-office declined: %+v", after.Attendees)
+	}
+
+	if err = DeleteOutOfOffice("1", strconv.FormatUint(ooo.ID, 10)); err != nil {
+		t.Fatalf("DeleteOutOfOffice() error = %v", err)
+	}
+	if err = DeleteOutOfOffice("1", strconv.FormatUint(ooo.ID, 10)); !errors.Is(err, models.ErrOutOfOfficeNotFound) {
+		t.Errorf("DeleteOutOfOffice() error = %v, want %v", err, models.ErrOutOfOfficeNotFound)
+	}
+	if _, err = AddOutOfOffice("1", "2024-01-18", "2024-01-17", ""); !errors.Is(err, models.ErrInvalidRange) {
+		t.Errorf("AddOutOfOffice() error = %v, want %v", err, models.ErrInvalidRange)
+	}
+}
+
+func TestUpdateAvailabilityConcurrently(t *testing.T) {
+	storage.Clear()
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := AddOutOfOffice("1", "2024-01-17", "2024-01-18", ""); err != nil {
+				t.Errorf("AddOutOfOffice() error = %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := UpdateAvailability("1", WithAutoDecline("true")); err != nil {
+				t.Errorf("UpdateAvailability() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	availability, err := GetAvailability("1")
+	if err != nil {
+		t.Fatalf("GetAvailability() error = %v", err)
+	}
+	if len(availability.OutOfOffice) != writers || !availability.AutoDecline {
+		t.Errorf("Expected %d out-of-office ranges with auto decline, got %+v", writers, availability)
+	}
+
+	if _, err = UpdateAvailability("1", WithAutoDecline("false"), WithAutoDecline("maybe")); !errors.Is(err, models.ErrInvalidAutoDecline) {
+		t.Errorf("UpdateAvailability() error = %v, want %v", err, models.ErrInvalidAutoDecline)
+	}
+	if availability, _ = GetAvailability("1"); !availability.AutoDecline {
+		t.Errorf("A failed update should not be stored")
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// CreateCalendar adds a calendar named name to userID. The color, time zone
+// and conflict mode are optional.
+func CreateCalendar(userID, name, color, timeZone, conflictMode string) (*models.Calendar, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := &models.Calendar{UserID: uID, CalendarID: storage.GetNewEventID()}
+	if err = setCalendarFields(calendar, name, color, timeZone, conflictMode); err != nil {
+		return nil, err
+	}
+	if err = storage.CreateCalendar(calendar); err != nil {
+		return nil, err
+	}
+	return calendar, nil
+}
+
+// UpdateCalendar changes the fields that are given and keeps the others.
+// Value "none" clears the color, time zone or conflict mode.
+func UpdateCalendar(userID, calendarID, name, color, timeZone, conflictMode string) (*models.Calendar, error) {
+	uID, cID, err := parseCalendarID(userID, calendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar, err := storage.UpdateCalendar(uID, cID, func(calendar *models.Calendar) error {
+		return setCalendarFields(calendar, name, color, timeZone, conflictMode)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &calendar, nil
+}
+
+func DeleteCalendar(userID, calendarID string) error {
+	uID, cID, err := parseCalendarID(userID, calendarID)
+	if err != nil {
+		return err
+	}
+	return storage.DeleteCalendar(uID, cID)
+}
+
+// GetCalendars lists the named calendars of userID. Events outside of them
+// belong to the default calendar.
+func GetCalendars(userID string, opts ...QueryOption) ([]models.Calendar, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = queryAccess(uID, opts); err != nil {
+		return nil, err
+	}
+	return storage.GetCalendars(uID), nil
+}
+
+func parseCalendarID(userID, calendarID string) (uint64, uint64, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	cID, err := strconv.ParseUint(calendarID, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	if cID == models.DefaultCalendarID {
+		return 0, 0, models.ErrCalendarNotFound
+	}
+	return uID, cID, nil
+}
+
+// setCalendarFields sets the fields that are given; empty values keep the
+// current ones and "none" clears them.
+func setCalendarFields(calendar *models.Calendar, name, color, timeZone, conflictMode string) error {
+	if name == "" {
+		name = calendar.Name
+	}
+	if name == "" {
+		return models.ErrNameIsRequired
+	}
+	switch color {
+	case "":
+		color = calendar.Color
+	case "none":
+		color = ""
+	default:
+		if !colorPattern.MatchString(color) {
+			return models.ErrInvalidColor
+		}
+	}
+	switch timeZone {
+	case "":
+		timeZone = calendar.TimeZone
+	case "none":
+		timeZone = ""
+	default:
+		if _, err := time.LoadLocation(timeZone); err != nil {
+			return models.ErrInvalidTimeZone
+		}
+	}
+	switch conflictMode {
+	case "":
+		conflictMode = calendar.ConflictMode
+	case "none":
+		conflictMode = ""
+	case models.ConflictAllow, models.ConflictWarn, models.ConflictReject:
+	default:
+		return models.ErrInvalidConflictMode
+	}
+	calendar.Name = name
+	calendar.Color = color
+	calendar.TimeZone = timeZone
+	calendar.ConflictMode = conflictMode
+	return nil
+}
+
+// calendarLocation returns the time zone of a calendar of userID, UTC for
+// the default calendar and calendars without one.
+func calendarLocation(userID, calendarID uint64) *time.Location {
+	calendar, err := storage.GetCalendar(userID, calendarID)
+	if err != nil {
+		return time.UTC
+	}
+	return calendar.Location()
+}
+
+// inCalendarZone anchors the date of the event in the time zone of its
+// calendar, so that its time of day is read in that zone.
+func inCalendarZone(event *models.Event) {
+	loc := calendarLocation(event.UserID, event.CalendarID)
+	event.Date = time.Date(event.Date.Year(), event.Date.Month(), event.Date.Day(), 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+)
+
+// WithConflictMode checks the event for overlaps with other events of its
+// owner, including invitations that were not declined. Mode "warn" stores
+// the event anyway, "reject" fails with a *models.ConflictError and "allow"
+// skips the check. An empty mode uses the mode of the calendar of the event.
+// The overlapping events the actor may see are stored in conflicts when it
+// is not nil.
+func WithConflictMode(mode string, conflicts *[]models.Event) EventOption {
+	return eventOptionFunc(func(r *eventRequest) error {
+		switch mode {
+		case "", models.ConflictAllow, models.ConflictWarn, models.ConflictReject:
+		default:
+			return models.ErrInvalidConflictMode
+		}
+		r.conflictMode = mode
+		r.conflicts = conflicts
+		return nil
+	})
+}
+
+// conflictChecks returns the storage checks that apply the conflict mode of
+// the request.
+func conflictChecks(r *eventRequest) []storage.Check {
+	mode := r.conflictMode
+	if mode == "" {
+		if calendar, err := storage.GetCalendar(r.event.UserID, r.event.CalendarID); err == nil {
+			mode = calendar.ConflictMode
+		}
+	}
+	if mode == "" || mode == models.ConflictAllow {
+		return nil
+	}
+
+	return []storage.Check{func(overlapping []models.Event) error {
+		r.overlapping = overlapping
+		if mode == models.ConflictReject && len(overlapping) > 0 {
+			return &models.ConflictError{Events: overlapping}
+		}
+		return nil
+	}}
+}
+
+// reportConflicts hands the events found by the conflict checks of the
+// request on, as far as its actor may see them, and returns err. Checks run
+// under the storage lock, so access is only checked afterwards.
+func reportConflicts(r *eventRequest, err error) error {
+	visible := visibleConflicts(r.actor, r.event.UserID, r.overlapping)
+	if r.conflicts != nil {
+		*r.conflicts = visible
+	}
+	var conflict *models.ConflictError
+	if errors.As(err, &conflict) {
+		conflict.Events = visible
+	}
+	return err
+}
+
+// visibleConflicts returns the events overlapping an event of owner as actor
+// sees them. Invitations of owner are seen at the access of actor to their
+// organizer and left out without it, unless actor is owner.
+func visibleConflicts(actor *uint64, owner uint64, events []models.Event) []models.Event {
+	result := make([]models.Event, 0, len(events))
+	ownerLevel, err := access(actor, owner, models.AccessFreeBusy)
+	if err != nil {
+		return result
+	}
+	for _, event := range events {
+		level := ownerLevel
+		if event.UserID != owner && level != accessOwner {
+			if level, err = access(actor, event.UserID, models.AccessFreeBusy); err != nil {
+				continue
+			}
+		}
+		if visible, ok := visibleEvent(event, level); ok {
+			result = append(result, visible)
+		}
+	}
+	return result
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxFields           = 32
+	maxFieldChoices     = 50
+	maxFieldValueLength = 1024
+)
+
+var fieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// DefineField adds a custom field of fieldType to the schema of a calendar
+// of userID, or replaces the field of the same name. Enum fields take their
+// choices from the comma separated values; required is "true" or empty.
+// Events keep their values until they are written again, when they have to
+// match the schema. Calendar "0" or "" is the default calendar.
+func DefineField(userID, calendarID, name, fieldType, values, required string) (*models.FieldDefinition, error) {
+	uID, cID, err := parseSchemaCalendar(userID, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	field := &models.FieldDefinition{Name: name, Type: fieldType}
+	if !fieldNamePattern.MatchString(name) {
+		return nil, models.ErrInvalidField
+	}
+	switch required {
+	case "", "false":
+	case "true":
+		field.Required = true
+	default:
+		return nil, models.ErrInvalidField
+	}
+
+	switch fieldType {
+	case models.FieldString, models.FieldNumber, models.FieldDate, models.FieldURL:
+		if values != "" {
+			return nil, models.ErrInvalidField
+		}
+	case models.FieldEnum:
+		for _, value := range strings.Split(values, ",") {
+			value = strings.TrimSpace(value)
+			if value == "" || utf8.RuneCountInString(value) > maxLabelLength || slices.Contains(field.Values, value) {
+				return nil, models.ErrInvalidField
+			}
+			field.Values = append(field.Values, value)
+		}
+		if len(field.Values) > maxFieldChoices {
+			return nil, models.ErrInvalidField
+		}
+	default:
+		return nil, models.ErrInvalidField
+	}
+
+	// The field replaces the one with the same name or is added, as long as
+	// the schema has room left.
+	err = storage.UpdateFields(uID, cID, func(schema []models.FieldDefinition) ([]models.FieldDefinition, error) {
+		schema = slices.Clone(schema)
+		if i := slices.IndexFunc(schema, func(f models.FieldDefinition) bool { return f.Name == name }); i >= 0 {
+			schema[i] = *field
+			return schema, nil
+		}
+		if len(schema) >= maxFields {
+			return nil, models.ErrInvalidField
+		}
+		return append(schema, *field), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return field, nil
+}
+
+// DeleteField removes a custom field from the schema of a calendar. Events
+// drop their values of it when they are written again.
+func DeleteField(userID, calendarID, name string) error {
+	uID, cID, err := parseSchemaCalendar(userID, calendarID)
+	if err != nil {
+		return err
+	}
+	return storage.DeleteField(uID, cID, name)
+}
+
+// GetFields returns the custom fields of a calendar of userID. It needs read
+// access.
+func GetFields(userID, calendarID string, opts ...QueryOption) ([]models.FieldDefinition, error) {
+	uID, cID, err := parseSchemaCalendar(userID, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	q, err := newEventQuery(opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = access(q.actor, uID, models.AccessRead); err != nil {
+		return nil, err
+	}
+	return storage.GetFields(uID, cID), nil
+}
+
+func parseSchemaCalendar(userID, calendarID string) (uint64, uint64, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	if calendarID == "" {
+		return uID, models.DefaultCalendarID, nil
+	}
+	cID, err := strconv.ParseUint(calendarID, 10, 64)
+	if err != nil {
+		return 0, 0, models.ErrCalendarNotFound
+	}
+	return uID, cID, nil
+}
+
+// WithField sets the value of a custom field of the calendar of the event.
+// An empty value removes it.
+func WithField(name, value string) EventOption {
+	return eventOptionFunc(func(r *eventRequest) error {
+		if r.fields == nil {
+			r.fields = make(map[string]string)
+		}
+		r.fields[name] = value
+		return nil
+	})
+}
+
+// applyFields sets the values of custom fields given to event and checks
+// all its values against the schema of its calendar. Kept values of fields
+// that are not in the schema are dropped.
+func applyFields(event *models.Event, given map[string]string) error {
+	schema := storage.GetFields(event.UserID, event.CalendarID)
+	for name := range given {
+		if !slices.ContainsFunc(schema, func(f models.FieldDefinition) bool { return f.Name == name }) {
+			return models.ErrFieldNotFound
+		}
+	}
+
+	values := make(map[string]string)
+	for _, field := range schema {
+		value, ok := given[field.Name]
+		if !ok {
+			value = event.Fields[field.Name]
+		}
+		if value == "" {
+			if field.Required {
+				return models.ErrFieldIsRequired
+			}
+			continue
+		}
+		canonical, err := fieldValue(field, value)
+		if err != nil {
+			return err
+		}
+		values[field.Name] = canonical
+	}
+	event.Fields = values
+	if len(values) == 0 {
+		event.Fields = nil
+	}
+	return nil
+}
+
+// fieldValue checks value against the type of field and returns it in the
+// form it is stored and compared in.
+func fieldValue(field models.FieldDefinition, value string) (string, error) {
+	switch field.Type {
+	case models.FieldString:
+		if utf8.RuneCountInString(value) <= maxFieldValueLength {
+			return value, nil
+		}
+	case models.FieldNumber:
+		if n, ok := parseNumber(value); ok {
+			return strconv.FormatFloat(n, 'f', -1, 64), nil
+		}
+	case models.FieldEnum:
+		if slices.Contains(field.Values, value) {
+			return value, nil
+		}
+	case models.FieldDate:
+		if date, err := time.Parse(DateFormat, value); err == nil {
+			return date.Format(DateFormat), nil
+		}
+	case models.FieldURL:
+		u, err := url.Parse(value)
+		if err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && len(value) <= maxFieldValueLength {
+			return u.String(), nil
+		}
+	}
+	return "", models.ErrInvalidFieldValue
+}
+
+func parseNumber(value string) (float64, bool) {
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, false
+	}
+	return n, true
+}
+
+// fieldFilter matches events whose custom field name equals value or, when
+// ranged, lies between min and max.
+type fieldFilter struct {
+	name     string
+	value    string
+	ranged   bool
+	min, max string
+}
+
+// WithFieldValue limits a read to events whose custom field name has value.
+// Values "min..max" select a range, where either bound may be left out;
+// numbers compare by value and other values, such as dates, as text.
+func WithFieldValue(name, value string) QueryOption {
+	return queryOptionFunc(func(q *eventQuery) error {
+		if !fieldNamePattern.MatchString(name) || value == "" {
+			return models.ErrInvalidFieldValue
+		}
+		filter := fieldFilter{name: name, value: value}
+		if low, high, ok := strings.Cut(value, ".."); ok {
+			if low == "" && high == "" {
+				return models.ErrInvalidFieldValue
+			}
+			filter.ranged, filter.min, filter.max = true, low, high
+		}
+		q.fields = append(q.fields, filter)
+		return nil
+	})
+}
+
+func (f fieldFilter) match(event models.Event) bool {
+	value, ok := event.Fields[f.name]
+	if !ok {
+		return false
+	}
+	if !f.ranged {
+		return compareFieldValues(value, f.value) == 0
+	}
+	return (f.min == "" || compareFieldValues(value, f.min) >= 0) &&
+		(f.max == "" || compareFieldValues(value, f.max) <= 0)
+}
+
+// compareFieldValues compares numbers by value and other values as text,
+// ignoring case.
+func compareFieldValues(a, b string) int {
+	if x, ok := parseNumber(a); ok {
+		if y, ok := parseNumber(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestCustomFields(t *testing.T) {
+	storage.Clear()
+
+	calendar, err := CreateCalendar("1", "Support", "", "", "")
+	if err != nil {
+		t.Fatalf("CreateCalendar() error = %v", err)
+	}
+	cID := strconv.FormatUint(calendar.CalendarID, 10)
+	for _, field := range [][4]string{
+		{"ticket", models.FieldString, "", "true"},
+		{"cost", models.FieldNumber, "", ""},
+		{"tier", models.FieldEnum, "gold, silver", ""},
+		{"due", models.FieldDate, "", ""},
+		{"link", models.FieldURL, "", ""},
+	} {
+		if _, err = DefineField("1", cID, field[0], field[1], field[2], field[3]); err != nil {
+			t.Fatalf("DefineField(%q) error = %v", field, err)
+		}
+	}
+	for _, field := range [][3]string{{"Ticket", models.FieldString, ""}, {"size", "color", ""}, {"tier", models.FieldEnum, ""}} {
+		if _, err = DefineField("1", cID, field[0], field[1], field[2], ""); !errors.Is(err, models.ErrInvalidField) {
+			t.Errorf("DefineField(%q) error = %v, want %v", field, err, models.ErrInvalidField)
+		}
+	}
+	if _, err = DefineField("1", "999", "ticket", models.FieldString, "", ""); !errors.Is(err, models.ErrCalendarNotFound) {
+		t.Errorf("DefineField() in unknown calendar error = %v", err)
+	}
+
+	create := func(date string, opts ...EventOption) (*models.Event, error) {
+		return CreateEvent("1", date, "Call", "", append([]EventOption{InCalendar(cID)}, opts...)...)
+	}
+	event, err := create("2024-01-15", WithField("ticket", "OPS-1"), WithField("cost", "12.50"), WithField("tier", "gold"),
+		WithField("due", "2024-02-01"), WithField("link", "https://tracker.example.com/OPS-1"))
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if event.Fields["cost"] != "12.5" {
+		t.Errorf("Fields = %v", event.Fields)
+	}
+	if _, err = create("2024-01-16", WithField("ticket", "OPS-2"), WithField("cost", "300")); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if _, err = create("2024-01-17", WithField("ticket", "OPS-3"), WithField("tier", "silver")); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+
+	for _, test := range []struct {
+		opts []EventOption
+		want error
+	}{
+		{nil, models.ErrFieldIsRequired},
+		{[]EventOption{WithField("ticket", "X"), WithField("cost", "cheap")}, models.ErrInvalidFieldValue},
+		{[]EventOption{WithField("ticket", "X"), WithField("tier", "bronze")}, models.ErrInvalidFieldValue},
+		{[]EventOption{WithField("ticket", "X"), WithField("due", "01/02/2024")}, models.ErrInvalidFieldValue},
+		{[]EventOption{WithField("ticket", "X"), WithField("link", "tracker")}, models.ErrInvalidFieldValue},
+		{[]EventOption{WithField("ticket", "X"), WithField("customer", "ACME")}, models.ErrFieldNotFound},
+	} {
+		if _, err = create("2024-01-18", test.opts...); !errors.Is(err, test.want) {
+			t.Errorf("CreateEvent() error = %v, want %v", err, test.want)
+		}
+	}
+
+	id := strconv.FormatUint(event.EventID, 10)
+	updated, err := UpdateEvent("1", id, "2024-01-15", "Call", "", WithField("tier", ""))
+	if err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if _, ok := updated.Fields["tier"]; ok || updated.Fields["ticket"] != "OPS-1" {
+		t.Errorf("Fields after update = %v", updated.Fields)
+	}
+	if _, err = UpdateEvent("1", id, "2024-01-15", "Call", "", WithField("ticket", "")); !errors.Is(err, models.ErrFieldIsRequired) {
+		t.Errorf("Clearing required field error = %v", err)
+	}
+
+	tickets := func(opts ...QueryOption) []string {
+		t.Helper()
+		events, err := GetEventsBetween("1", "2024-01-01", "2024-02-01", opts...)
+		if err != nil {
+			t.Fatalf("GetEventsBetween() error = %v", err)
+		}
+		result := make([]string, 0, len(events))
+		for _, event := range events {
+			result = append(result, event.Fields["ticket"])
+		}
+		return result
+	}
+	assertStrings(t, tickets(WithFieldValue("cost", "10..100")), "OPS-1")
+	assertStrings(t, tickets(WithFieldValue("cost", "100..")), "OPS-2")
+	assertStrings(t, tickets(WithFieldValue("tier", "silver")), "OPS-3")
+	assertStrings(t, tickets(WithFieldValue("due", "..2024-02-28")), "OPS-1")
+	assertStrings(t, tickets(WithFieldValue("ticket", "ops-2")), "OPS-2")
+
+	if _, err = ShareCalendar("1", "3", models.AccessFreeBusy); err != nil {
+		t.Fatalf("ShareCalendar() error = %v", err)
+	}
+	if _, err = GetEventsBetween("1", "2024-01-01", "2024-02-01", Actor(3), WithFieldValue("tier", "gold")); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("Free/busy filter error = %v, want %v", err, models.ErrForbidden)
+	}
+
+	if err = DeleteField("1", cID, "ticket"); err != nil {
+		t.Fatalf("DeleteField() error = %v", err)
+	}
+	updated, err = UpdateEvent("1", id, "2024-01-15", "Call", "")
+	if err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if _, ok := updated.Fields["ticket"]; ok {
+		t.Errorf("Values of deleted fields kept: %v", updated.Fields)
+	}
+}
+
+func TestDefineField_Concurrent(t *testing.T) {
+	storage.Clear()
+
+	var wg sync.WaitGroup
+	for i := range maxFields + 10 {
+		wg.Go(func() {
+			_, err := DefineField("1", "0", "field"+strconv.Itoa(i), models.FieldString, "", "")
+			if err != nil && !errors.Is(err, models.ErrInvalidField) {
+				t.Errorf("DefineField() error = %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if got := len(storage.GetFields(1, 0)); got != maxFields {
+		t.Errorf("Expected %d fields, got %d", maxFields, got)
+	}
+}
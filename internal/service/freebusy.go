@@ -0,0 +1,127 @@
+package service
+
+import (
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxFreeBusyRange = 92 * 24 * time.Hour
+
+// GetFreeBusy returns when each user of the comma separated userIDs is busy
+// between from and to. Reading the free/busy time of another user requires
+// at least free/busy access to their calendar.
+func GetFreeBusy(userIDs, from, to string, opts ...QueryOption) (*models.FreeBusyResult, error) {
+	start, end, err := parseRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	q, err := newEventQuery(opts)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := parseUserIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.FreeBusyResult{From: start, To: end, Users: make([]models.FreeBusy, 0, len(ids))}
+	for _, uID := range ids {
+		if _, err = access(actorOf(q.actor, q.actorFor, uID), uID, models.AccessFreeBusy); err != nil {
+			return nil, err
+		}
+		result.Users = append(result.Users, models.FreeBusy{
+			UserID:      uID,
+			Busy:        busyIntervals(uID, start, end),
+			Unavailable: unavailableIntervals(uID, start, end),
+		})
+	}
+	return result, nil
+}
+
+// busyIntervals merges the busy events of userID and the invitations it did
+// not decline into sorted, disjoint intervals within [from, to).
+func busyIntervals(userID uint64, from, to time.Time) []models.Interval {
+	events := slices.DeleteFunc(storage.GetEventsBetween(userID, from, to), func(event models.Event) bool { return !event.Busy() })
+	firstDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	for _, event := range storage.GetInvitedEvents(userID, firstDay.AddDate(0, 0, -1), to.AddDate(0, 0, 1)) {
+		if !event.Declined(userID) && event.Busy() && event.Start().Before(to) && event.End().After(from) {
+			events = append(events, event)
+		}
+	}
+
+	intervals := make([]models.Interval, 0, len(events))
+	for _, event := range events {
+		intervals = append(intervals, models.Interval{Start: maxTime(event.Start(), from), End: minTime(event.End(), to)})
+	}
+	return mergeIntervals(intervals)
+}
+
+// mergeIntervals sorts intervals and joins the ones that overlap or touch.
+func mergeIntervals(intervals []models.Interval) []models.Interval {
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].Start.Before(intervals[j].Start) })
+
+	result := make([]models.Interval, 0, len(intervals))
+	for _, interval := range intervals {
+		if n := len(result); n > 0 && !interval.Start.After(result[n-1].End) {
+			result[n-1].End = maxTime(result[n-1].End, interval.End)
+			continue
+		}
+		result = append(result, interval)
+	}
+	return result
+}
+
+// ParseTime accepts an RFC 3339 timestamp or a date, which means its
+// midnight in UTC.
+func ParseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse(DateFormat, value)
+}
+
+func parseRange(from, to string) (time.Time, time.Time, error) {
+	start, err := ParseTime(from)
+	if err != nil {
+		return time.Time{}, time.Time{}, models.ErrInvalidRange
+	}
+	end, err := ParseTime(to)
+	if err != nil {
+		return time.Time{}, time.Time{}, models.ErrInvalidRange
+	}
+	if !end.After(start) || end.Sub(start) > maxFreeBusyRange {
+		return time.Time{}, time.Time{}, models.ErrInvalidRange
+	}
+	return start, end, nil
+}
+
+func parseUserIDs(value string) ([]uint64, error) {
+	var ids []uint64
+	for _, part := range strings.Split(value, ",") {
+		uID, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, uID)
+	}
+	return ids, nil
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,14 @@
+package service
+
+import (
+	"slices"
+	"testing"
+)
+
+// assertStrings compares a listing, such as titles of events, in order.
+func assertStrings(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if !slices.Equal(got, want) {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"http-calendar/internal/mail"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"http-calendar/internal/webhook"
+	"log"
+	"strconv"
+	"time"
+)
+
+const replyTimeout = time.Minute
+
+var sender *mail.Sender
+
+// SetMailSender enables invitation and reply emails. Without a sender they
+// are not sent.
+func SetMailSender(s *mail.Sender) {
+	sender = s
+}
+
+// SendInvitations emails an invitation to every attendee of the event with a
+// known address and returns the attendees that were invited.
+func SendInvitations(ctx context.Context, userID, eventID string, opts ...EventOption) ([]models.Attendee, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	eID, err := strconv.ParseUint(eventID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	req, err := newEventRequest(&models.Event{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = access(req.actor, uID, models.AccessWrite); err != nil {
+		return nil, err
+	}
+	if sender == nil {
+		return nil, models.ErrMailDisabled
+	}
+
+	event, err := storage.GetEvent(uID, eID)
+	if err != nil {
+		return nil, err
+	}
+	organizer, _ := UserEmail(uID)
+	event = withAttendeeEmails(event)
+
+	invited := make([]models.Attendee, 0, len(event.Attendees))
+	for _, attendee := range event.Attendees {
+		if attendee.Email == "" {
+			continue
+		}
+		msg, err := mail.InvitationMessage(attendee.Email, organizer, event)
+		if err != nil {
+			return invited, err
+		}
+		if err = sender.Send(ctx, msg); err != nil {
+			return invited, err
+		}
+		invited = append(invited, attendee)
+	}
+	return invited, nil
+}
+
+// RSVP records the answer of userID to the invitation to an event of
+// organizerID and notifies the organizer.
+func RSVP(userID, organizerID, eventID, status string) (*models.Event, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	oID, err := strconv.ParseUint(organizerID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	eID, err := strconv.ParseUint(eventID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case models.StatusAccepted, models.StatusDeclined, models.StatusTentative, models.StatusNeedsAction:
+	default:
+		return nil, models.ErrInvalidStatus
+	}
+
+	for attempt := 1; ; attempt++ {
+		event, err := rsvp(uID, oID, eID, status)
+		if errors.Is(err, models.ErrEventChanged) && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		webhook.Notify(models.ChangeUpdated, *event)
+		notifyOrganizer(*event, uID, status)
+		return event, nil
+	}
+}
+
+// rsvp sets the status of userID on the stored event and writes it unless
+// the event was changed in the meantime.
+func rsvp(uID, oID, eID uint64, status string) (*models.Event, error) {
+	event, version, err := storage.GetEventAt(oID, eID)
+	if err != nil {
+		return nil, err
+	}
+	if !setStatus(&event, uID, status) {
+		return nil, models.ErrNotInvited
+	}
+	if err = storage.UpdateEventAt(&event, version); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func setStatus(event *models.Event, userID uint64, status string) bool {
+	attendees := make([]models.Attendee, len(event.Attendees))
+	copy(attendees, event.Attendees)
+	for i := range attendees {
+		if attendees[i].UserID == userID {
+			attendees[i].Status = status
+			event.Attendees = attendees
+			return true
+		}
+	}
+	return false
+}
+
+// notifyOrganizer emails the reply to the organizer in the background; the
+// answer is recorded even when the organizer can not be reached.
+func notifyOrganizer(event models.Event, userID uint64, status string) {
+	to, ok := UserEmail(event.UserID)
+	if sender == nil || !ok {
+		return
+	}
+	attendee, ok := UserEmail(userID)
+	if !ok {
+		attendee = "User " + strconv.FormatUint(userID, 10)
+	}
+	msg, err := mail.ReplyMessage(to, attendee, status, withAttendeeEmails(event))
+	if err != nil {
+		log.Printf("Failed build reply of user %d: %v\n", userID, err)
+		return
+	}
+	s := sender
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), replyTimeout)
+		defer cancel()
+		if err := s.Send(ctx, msg); err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("Failed send reply of user %d to %s: %v\n", userID, to, err)
+		}
+	}()
+}
+
+// withAttendeeEmails fills in the addresses of attendees that are users.
+func withAttendeeEmails(event models.Event) models.Event {
+	attendees := make([]models.Attendee, len(event.Attendees))
+	for i, attendee := range event.Attendees {
+		if attendee.UserID != 0 {
+			attendee.Email, _ = UserEmail(attendee.UserID)
+		}
+		attendees[i] = attendee
+	}
+	event.Attendees = attendees
+	return event
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxAddressLength = 256
+	maxRadiusKm      = 1000
+)
+
+// WithLocation sets where the event takes place: an address, coordinates in
+// decimal degrees given both or not at all, and an http(s) link of a video
+// call. The parts given replace the location of an updated event; address
+// "none" removes it.
+func WithLocation(address, latitude, longitude, videoURL string) EventOption {
+	return eventOptionFunc(func(r *eventRequest) error {
+		if address == "none" {
+			r.event.Location = nil
+			return nil
+		}
+		if address == "" && latitude == "" && longitude == "" && videoURL == "" {
+			return nil
+		}
+		location, err := parseLocation(address, latitude, longitude, videoURL)
+		if err != nil {
+			return err
+		}
+		r.event.Location = location
+		return nil
+	})
+}
+
+func parseLocation(address, latitude, longitude, videoURL string) (*models.Location, error) {
+	location := &models.Location{Address: strings.TrimSpace(address)}
+	if utf8.RuneCountInString(location.Address) > maxAddressLength {
+		return nil, models.ErrInvalidLocation
+	}
+	if latitude != "" || longitude != "" {
+		p, err := parsePoint(latitude, longitude)
+		if err != nil {
+			return nil, models.ErrInvalidLocation
+		}
+		location.Latitude, location.Longitude = &p.Latitude, &p.Longitude
+	}
+	if videoURL != "" {
+		u, err := url.Parse(videoURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, models.ErrInvalidLocation
+		}
+		location.VideoURL = u.String()
+	}
+	return location, nil
+}
+
+func parsePoint(latitude, longitude string) (models.Point, error) {
+	lat, err := parseDegrees(latitude, 90)
+	if err != nil {
+		return models.Point{}, err
+	}
+	lon, err := parseDegrees(longitude, 180)
+	if err != nil {
+		return models.Point{}, err
+	}
+	return models.Point{Latitude: lat, Longitude: lon}, nil
+}
+
+func parseDegrees(value string, limit float64) (float64, error) {
+	degrees, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(degrees) || math.Abs(degrees) > limit {
+		return 0, models.ErrInvalidGeoQuery
+	}
+	return degrees, nil
+}
+
+// GetEventsNear returns the events of userID between from and to that take
+// place within radius kilometers of the point at latitude and longitude,
+// the nearest first. Reading locations needs read access; events whose
+// details are hidden from the actor are left out.
+func GetEventsNear(userID, latitude, longitude, radius, from, to string, opts ...QueryOption) ([]models.NearbyEvent, error) {
+	center, err := parsePoint(latitude, longitude)
+	if err != nil {
+		return nil, models.ErrInvalidGeoQuery
+	}
+	km, err := strconv.ParseFloat(radius, 64)
+	if err != nil || !(km > 0 && km <= maxRadiusKm) {
+		return nil, models.ErrInvalidGeoQuery
+	}
+
+	events, err := locatedEvents(userID, models.Around(center, km), from, to, opts)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]models.NearbyEvent, 0, len(events))
+	for _, event := range events {
+		p, _ := event.Location.Point()
+		if distance := center.DistanceKm(p); distance <= km {
+			result = append(result, models.NearbyEvent{Event: event, DistanceKm: distance})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].DistanceKm < result[j].DistanceKm })
+	return result, nil
+}
+
+// GetEventsInArea returns the events of userID between from and to that take
+// place inside box, given as "south,west,north,east" in decimal degrees, in
+// order of start. A box whose west edge lies east of its east edge crosses
+// the antimeridian. Access is as for GetEventsNear.
+func GetEventsInArea(userID, box, from, to string, opts ...QueryOption) ([]models.Event, error) {
+	parts := strings.Split(box, ",")
+	if len(parts) != 4 {
+		return nil, models.ErrInvalidGeoQuery
+	}
+	southWest, err := parsePoint(parts[0], parts[1])
+	if err != nil {
+		return nil, err
+	}
+	northEast, err := parsePoint(parts[2], parts[3])
+	if err != nil {
+		return nil, err
+	}
+	if southWest.Latitude > northEast.Latitude {
+		return nil, models.ErrInvalidGeoQuery
+	}
+	return locatedEvents(userID, models.BoundingBox{
+		MinLatitude:  southWest.Latitude,
+		MinLongitude: southWest.Longitude,
+		MaxLatitude:  northEast.Latitude,
+		MaxLongitude: northEast.Longitude,
+	}, from, to, opts)
+}
+
+// locatedEvents returns the events of userID inside box that overlap the
+// range from to and match the query, in order of start.
+func locatedEvents(userID string, box models.BoundingBox, from, to string, opts []QueryOption) ([]models.Event, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := parseRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	q, err := newEventQuery(opts)
+	if err != nil {
+		return nil, err
+	}
+	level, err := access(q.actor, uID, models.AccessRead)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.Event, 0)
+	for _, event := range storage.GetEventsInBox(uID, box, start, end) {
+		if q.match(event) && !hidesDetails(event, level) {
+			result = append(result, event)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Start().Equal(result[j].Start()) {
+			return result[i].Start().Before(result[j].Start())
+		}
+		return result[i].EventID < result[j].EventID
+	})
+	return result, nil
+}
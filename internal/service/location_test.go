@@ -0,0 +1,124 @@
+package service
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"strconv"
+	"testing"
+)
+
+func TestLocation(t *testing.T) {
+	storage.Clear()
+
+	event, err := CreateEvent("1", "2024-01-15", "Call", "", WithLocation("", "", "", "https://meet.example.com/abc"))
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if event.Location == nil || event.Location.VideoURL != "https://meet.example.com/abc" {
+		t.Errorf("Location = %+v", event.Location)
+	}
+
+	for _, location := range [][4]string{
+		{"Office", "52.5", "", ""},
+		{"Office", "91", "13.4", ""},
+		{"Office", "52.5", "-181", ""},
+		{"Office", "north", "13.4", ""},
+		{"", "", "", "ftp://files.example.com"},
+		{"", "", "", "meet.example.com"},
+	} {
+		_, err = CreateEvent("1", "2024-01-15", "Invalid", "", WithLocation(location[0], location[1], location[2], location[3]))
+		if !errors.Is(err, models.ErrInvalidLocation) {
+			t.Errorf("WithLocation(%q) error = %v, want %v", location, err, models.ErrInvalidLocation)
+		}
+	}
+
+	id := strconv.FormatUint(event.EventID, 10)
+	updated, err := UpdateEvent("1", id, "2024-01-15", "Call", "", WithTime("10:00"))
+	if err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if updated.Location == nil {
+		t.Error("Update drops the location")
+	}
+	updated, err = UpdateEvent("1", id, "2024-01-15", "Call", "", WithLocation("none", "", "", ""))
+	if err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if updated.Location != nil {
+		t.Errorf("Location = %+v, want none", updated.Location)
+	}
+}
+
+func TestEventsByLocation(t *testing.T) {
+	storage.Clear()
+
+	for _, event := range []struct{ date, title, lat, lon string }{
+		{"2024-01-15", "Berlin office", "52.520", "13.405"},
+		{"2024-01-16", "Potsdam visit", "52.391", "13.065"},
+		{"2024-01-15", "Hamburg fair", "53.551", "9.993"},
+		{"2024-03-01", "Berlin later", "52.520", "13.405"},
+		{"2024-01-15", "Fiji", "-17.7", "179.9"},
+		{"2024-01-15", "Samoa", "-13.8", "-172.1"},
+	} {
+		if _, err := CreateEvent("1", event.date, event.title, "", WithLocation("", event.lat, event.lon, "")); err != nil {
+			t.Fatalf("CreateEvent() error = %v", err)
+		}
+	}
+	if _, err := CreateEvent("1", "2024-01-15", "Berlin private", "", WithLocation("", "52.52", "13.40", ""),
+		WithVisibility(models.VisibilityPrivate)); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if _, err := CreateEvent("1", "2024-01-15", "Nowhere", ""); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+
+	near, err := GetEventsNear("1", "52.52", "13.40", "50", "2024-01-01", "2024-02-01")
+	if err != nil {
+		t.Fatalf("GetEventsNear() error = %v", err)
+	}
+	titles := make([]string, 0, len(near))
+	for _, result := range near {
+		titles = append(titles, result.Event.Title)
+	}
+	assertStrings(t, titles, "Berlin private", "Berlin office", "Potsdam visit")
+	if d := near[2].DistanceKm; d < 20 || d > 30 {
+		t.Errorf("Distance to Potsdam = %.1f km", d)
+	}
+
+	area := func(events []models.Event, err error) []string {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("GetEventsInArea() error = %v", err)
+		}
+		result := make([]string, 0, len(events))
+		for _, event := range events {
+			result = append(result, event.Title)
+		}
+		return result
+	}
+	assertStrings(t, area(GetEventsInArea("1", "52,9,54,11", "2024-01-01", "2024-02-01")), "Hamburg fair")
+	assertStrings(t, area(GetEventsInArea("1", "-20,170,-10,-170", "2024-01-01", "2024-02-01")), "Fiji", "Samoa")
+	assertStrings(t, area(GetEventsInArea("1", "52,13,53,14", "2024-02-15", "2024-03-15")), "Berlin later")
+
+	if _, err = ShareCalendar("1", "2", models.AccessRead); err != nil {
+		t.Fatalf("ShareCalendar() error = %v", err)
+	}
+	assertStrings(t, area(GetEventsInArea("1", "52,13,53,14", "2024-01-01", "2024-02-01", Actor(2))),
+		"Berlin office", "Potsdam visit")
+	if _, err = ShareCalendar("1", "3", models.AccessFreeBusy); err != nil {
+		t.Fatalf("ShareCalendar() error = %v", err)
+	}
+	if _, err = GetEventsNear("1", "52.52", "13.40", "50", "2024-01-01", "2024-02-01", Actor(3)); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("Free/busy viewer error = %v, want %v", err, models.ErrForbidden)
+	}
+
+	for _, query := range [][3]string{{"91", "0", "10"}, {"0", "0", "0"}, {"0", "0", "5000"}} {
+		if _, err = GetEventsNear("1", query[0], query[1], query[2], "2024-01-01", "2024-02-01"); !errors.Is(err, models.ErrInvalidGeoQuery) {
+			t.Errorf("GetEventsNear(%q) error = %v, want %v", query, err, models.ErrInvalidGeoQuery)
+		}
+	}
+	if _, err = GetEventsInArea("1", "54,9,52,11", "2024-01-01", "2024-02-01"); !errors.Is(err, models.ErrInvalidGeoQuery) {
+		t.Errorf("Inverted box error = %v, want %v", err, models.ErrInvalidGeoQuery)
+	}
+}
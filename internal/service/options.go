@@ -0,0 +1,301 @@
+package service
+
+import (
+	"http-calendar/internal/models"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxAlarmMinutes    = 4 * 7 * 24 * 60
+	maxDurationMinutes = 7 * 24 * 60
+)
+
+// EventOption adjusts an event being created or updated.
+type EventOption interface {
+	applyEvent(*eventRequest) error
+}
+
+// QueryOption adjusts a read of events.
+type QueryOption interface {
+	applyQuery(*eventQuery) error
+}
+
+type eventRequest struct {
+	event        *models.Event
+	actor        *uint64
+	conflictMode string
+	conflicts    *[]models.Event
+	// overlapping holds the events found by the conflict checks, see
+	// reportConflicts.
+	overlapping []models.Event
+	// fields holds the custom field values given, see WithField.
+	fields map[string]string
+}
+
+type eventQuery struct {
+	actor     *uint64
+	actorFor  ActorFor
+	calendars map[uint64]bool
+	tasks     *[]models.Task
+
+	tags     []string
+	allTags  bool
+	category string
+	title    string
+	fields   []fieldFilter
+}
+
+type queryOptionFunc func(*eventQuery) error
+
+func (f queryOptionFunc) applyQuery(q *eventQuery) error {
+	return f(q)
+}
+
+type eventOptionFunc func(*eventRequest) error
+
+func (f eventOptionFunc) applyEvent(r *eventRequest) error {
+	return f(r)
+}
+
+// Actor names the user performing a request, whose access to the calendar
+// of the event owner is checked. Requests without an Actor are trusted.
+type Actor uint64
+
+func (a Actor) applyEvent(r *eventRequest) error {
+	actor := uint64(a)
+	r.actor = &actor
+	return nil
+}
+
+func (a Actor) applyQuery(q *eventQuery) error {
+	actor := uint64(a)
+	q.actor = &actor
+	return nil
+}
+
+// ActorFor names the actor per calendar owner for reads that span several
+// users. Owners it returns no actor for are trusted, as without an Actor.
+type ActorFor func(owner uint64) (Actor, bool)
+
+func (f ActorFor) applyQuery(q *eventQuery) error {
+	q.actorFor = f
+	return nil
+}
+
+func (f ActorFor) applySlot(s *slotSearch) error {
+	s.actorFor = f
+	return nil
+}
+
+// actorOf returns the actor checked against the calendar of owner.
+func actorOf(actor *uint64, actorFor ActorFor, owner uint64) *uint64 {
+	if actorFor == nil {
+		return actor
+	}
+	a, ok := actorFor(owner)
+	if !ok {
+		return nil
+	}
+	id := uint64(a)
+	return &id
+}
+
+// WithTime sets the start time of the event in HH:MM format. Value "none"
+// makes it all-day; an empty value keeps the time of an updated event and
+// creates an all-day one.
+func WithTime(value string) EventOption {
+	return eventOptionFunc(func(r *eventRequest) error {
+		switch value {
+		case "":
+			return nil
+		case "none":
+			r.event.Time, r.event.Duration = "", 0
+			return nil
+		}
+		if _, err := time.Parse(models.TimeFormat, value); err != nil {
+			return models.ErrInvalidTime
+		}
+		r.event.Time = value
+		return nil
+	})
+}
+
+// WithDuration sets the length of a timed event in minutes.
+func WithDuration(value string) EventOption {
+	return eventOptionFunc(func(r *eventRequest) error {
+		if value == "" {
+			return nil
+		}
+		minutes, err := strconv.Atoi(value)
+		if err != nil || minutes <= 0 || minutes > maxDurationMinutes {
+			return models.ErrInvalidDuration
+		}
+		r.event.Duration = minutes
+		return nil
+	})
+}
+
+// WithAlarms sets the alarms of the event from a comma separated list of
+// minutes before its start, for example "15,60". Value "none" removes them;
+// an empty value keeps the alarms of an updated event.
+func WithAlarms(value string) EventOption {
+	return eventOptionFunc(func(r *eventRequest) error {
+		switch value {
+		case "":
+			return nil
+		case "none":
+			r.event.Alarms = nil
+			return nil
+		}
+		r.event.Alarms = nil
+		for _, part := range strings.Split(value, ",") {
+			minutes, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || minutes < 0 || minutes > maxAlarmMinutes {
+				return models.ErrInvalidAlarm
+			}
+			r.event.Alarms = append(r.event.Alarms, models.Alarm{MinutesBefore: minutes})
+		}
+		return nil
+	})
+}
+
+// InCalendar puts the event into a calendar of its owner. An empty value
+// keeps the calendar of an updated event and uses the default calendar for a
+// new one.
+func InCalendar(value string) EventOption {
+	return eventOptionFunc(func(r *eventRequest) error {
+		if value == "" {
+			return nil
+		}
+		calendarID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return models.ErrCalendarNotFound
+		}
+		r.event.CalendarID = calendarID
+		return nil
+	})
+}
+
+// WithAttendees invites a comma separated list of user IDs and email
+// addresses to the event, for example "2,bob@example.com". Attendees that
+// were already invited keep their status. Value "none" removes them; an
+// empty value keeps the attendees of an updated event.
+func WithAttendees(value string) EventOption {
+	return eventOptionFunc(func(r *eventRequest) error {
+		switch value {
+		case "":
+			return nil
+		case "none":
+			r.event.Attendees = nil
+			return nil
+		}
+		var attendees []models.Attendee
+		for _, part := range strings.Split(value, ",") {
+			attendee, err := parseAttendee(strings.TrimSpace(part))
+			if err != nil {
+				return err
+			}
+			if attendee.UserID == r.event.UserID {
+				return models.ErrInvalidAttendee
+			}
+			if slices.ContainsFunc(attendees, attendee.Same) {
+				continue
+			}
+			if i := slices.IndexFunc(r.event.Attendees, attendee.Same); i >= 0 {
+				attendee.Status = r.event.Attendees[i].Status
+			}
+			attendees = append(attendees, attendee)
+		}
+		r.event.Attendees = attendees
+		return nil
+	})
+}
+
+func parseAttendee(value string) (models.Attendee, error) {
+	if userID, err := strconv.ParseUint(value, 10, 64); err == nil {
+		return models.Attendee{UserID: userID, Status: models.StatusNeedsAction}, nil
+	}
+	addr, err := mail.ParseAddress(value)
+	if err != nil {
+		return models.Attendee{}, models.ErrInvalidAttendee
+	}
+	return models.Attendee{Email: addr.Address, Status: models.StatusNeedsAction}, nil
+}
+
+// InCalendars limits a read to the events of a comma separated list of
+// calendars, for example "0,1700000000". An empty value reads all calendars.
+func InCalendars(value string) QueryOption {
+	return queryOptionFunc(func(q *eventQuery) error {
+		if value == "" {
+			return nil
+		}
+		q.calendars = make(map[uint64]bool)
+		for _, part := range strings.Split(value, ",") {
+			calendarID, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+			if err != nil {
+				return models.ErrCalendarNotFound
+			}
+			q.calendars[calendarID] = true
+		}
+		return nil
+	})
+}
+
+// match reports whether event passes the filters of the query.
+func (q *eventQuery) match(event models.Event) bool {
+	return (q.calendars == nil || q.calendars[event.CalendarID]) && q.matchContent(event)
+}
+
+// matchContent reports whether event passes the filters of the query on
+// its tags, category, title and custom fields.
+func (q *eventQuery) matchContent(event models.Event) bool {
+	for _, filter := range q.fields {
+		if !filter.match(event) {
+			return false
+		}
+	}
+	if q.category != "" && !strings.EqualFold(event.Category, q.category) {
+		return false
+	}
+	if q.title != "" && !strings.Contains(strings.ToLower(event.Title), q.title) {
+		return false
+	}
+	if len(q.tags) == 0 {
+		return true
+	}
+	tagged := func(tag string) bool { return slices.Contains(event.Tags, tag) }
+	if q.allTags {
+		return !slices.ContainsFunc(q.tags, func(tag string) bool { return !tagged(tag) })
+	}
+	return slices.ContainsFunc(q.tags, tagged)
+}
+
+// filtersContent reports whether the query filters on details that viewers
+// with free/busy access do not see.
+func (q *eventQuery) filtersContent() bool {
+	return len(q.tags) > 0 || q.category != "" || q.title != "" || len(q.fields) > 0
+}
+
+func newEventRequest(event *models.Event, opts []EventOption) (*eventRequest, error) {
+	r := &eventRequest{event: event}
+	for _, opt := range opts {
+		if err := opt.applyEvent(r); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func newEventQuery(opts []QueryOption) (*eventQuery, error) {
+	q := &eventQuery{}
+	for _, opt := range opts {
+		if err := opt.applyQuery(q); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
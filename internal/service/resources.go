@@ -0,0 +1,231 @@
+package service
+
+import (
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"http-calendar/internal/webhook"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+func CreateResource(ownerID, name, kind, capacity, quantity, approvalRequired string) (*models.Resource, error) {
+	oID, err := strconv.ParseUint(ownerID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, models.ErrNameIsRequired
+	}
+
+	resource := &models.Resource{ResourceID: storage.GetNewEventID(), OwnerID: oID, Name: name, Kind: kind, Quantity: 1}
+	if capacity != "" {
+		if resource.Capacity, err = strconv.Atoi(capacity); err != nil || resource.Capacity < 0 {
+			return nil, models.ErrInvalidResource
+		}
+	}
+	if quantity != "" {
+		if resource.Quantity, err = strconv.Atoi(quantity); err != nil || resource.Quantity < 1 {
+			return nil, models.ErrInvalidResource
+		}
+	}
+	if approvalRequired != "" {
+		if resource.ApprovalRequired, err = strconv.ParseBool(approvalRequired); err != nil {
+			return nil, models.ErrInvalidResource
+		}
+	}
+
+	if err = storage.CreateResource(resource); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// DeleteResource removes a resource of ownerID without bookings.
+func DeleteResource(ownerID, resourceID string) error {
+	oID, err := strconv.ParseUint(ownerID, 10, 64)
+	if err != nil {
+		return err
+	}
+	resource, err := getResource(resourceID)
+	if err != nil {
+		return err
+	}
+	if resource.OwnerID != oID {
+		return models.ErrForbidden
+	}
+	return storage.DeleteResource(resource.ResourceID)
+}
+
+func GetResources() []models.Resource {
+	return storage.GetResources()
+}
+
+// GetResourceAvailability returns when the resource is booked between from
+// and to. Pending bookings count as booked.
+func GetResourceAvailability(resourceID, from, to string) (*models.ResourceAvailability, error) {
+	start, end, err := parseRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	resource, err := getResource(resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	events := storage.GetBookedEvents(resource.ResourceID, start, end)
+	intervals := make([]models.Interval, 0, len(events))
+	for _, event := range events {
+		intervals = append(intervals, models.Interval{Start: maxTime(event.Start(), start), End: minTime(event.End(), end)})
+	}
+	return &models.ResourceAvailability{ResourceID: resource.ResourceID, Busy: mergeIntervals(intervals)}, nil
+}
+
+// FindResources returns the resources of kind, or of any kind when it is
+// empty, that hold at least minCapacity people and have a unit free for the
+// whole time between from and to.
+func FindResources(from, to, kind, minCapacity string) ([]models.Resource, error) {
+	start, end, err := parseRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	need := 0
+	if minCapacity != "" {
+		if need, err = strconv.Atoi(minCapacity); err != nil {
+			return nil, models.ErrInvalidResource
+		}
+	}
+
+	result := make([]models.Resource, 0)
+	for _, resource := range storage.GetResources() {
+		if kind != "" && resource.Kind != kind {
+			continue
+		}
+		if resource.Capacity > 0 && resource.Capacity < need {
+			continue
+		}
+		if len(storage.GetBookedEvents(resource.ResourceID, start, end)) >= resource.Quantity {
+			continue
+		}
+		result = append(result, resource)
+	}
+	return result, nil
+}
+
+// WithResources books a comma separated list of resources for the event.
+// Resources that were booked already keep the status of their booking,
+// unless the event moves, see resetMovedBookings.
+func WithResources(value string) EventOption {
+	return eventOptionFunc(func(r *eventRequest) error {
+		if value == "" {
+			return nil
+		}
+		if value == "none" {
+			r.event.Bookings = nil
+			return nil
+		}
+		var bookings []models.Booking
+		for _, part := range strings.Split(value, ",") {
+			resourceID, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+			if err != nil {
+				return models.ErrResourceNotFound
+			}
+			booking := models.Booking{ResourceID: resourceID}
+			same := func(b models.Booking) bool { return b.ResourceID == resourceID }
+			if slices.ContainsFunc(bookings, same) {
+				continue
+			}
+			if i := slices.IndexFunc(r.event.Bookings, same); i >= 0 {
+				booking.Status = r.event.Bookings[i].Status
+			}
+			bookings = append(bookings, booking)
+		}
+		r.event.Bookings = bookings
+		return nil
+	})
+}
+
+// resetMovedBookings clears the status of the bookings of event when it no
+// longer takes place at the time of previous, so that prepareBookings asks
+// for approval again.
+func resetMovedBookings(previous, event *models.Event) {
+	if event.Start().Equal(previous.Start()) && event.End().Equal(previous.End()) {
+		return
+	}
+	event.Bookings = slices.Clone(event.Bookings)
+	for i := range event.Bookings {
+		event.Bookings[i].Status = ""
+	}
+}
+
+// prepareBookings sets the status of new bookings of event and checks that
+// every booked resource holds its attendees. Whether the resources are free
+// is checked by storage when the event is written.
+func prepareBookings(event *models.Event) error {
+	event.Bookings = slices.Clone(event.Bookings)
+	for i, booking := range event.Bookings {
+		resource, err := storage.GetResource(booking.ResourceID)
+		if err != nil {
+			return err
+		}
+		if booking.Status == "" {
+			booking.Status = models.BookingConfirmed
+			if resource.ApprovalRequired && resource.OwnerID != event.UserID {
+				booking.Status = models.BookingPending
+			}
+			event.Bookings[i] = booking
+		}
+		if booking.Status != models.BookingRejected && resource.Capacity > 0 && len(event.Attendees)+1 > resource.Capacity {
+			return models.ErrCapacityExceeded
+		}
+	}
+	return nil
+}
+
+// ReviewBooking confirms or rejects the pending booking of a resource by an
+// event of organizerID and returns the booking. Only the owner of the
+// resource may review it.
+func ReviewBooking(userID, resourceID, organizerID, eventID string, approve bool) (*models.Booking, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	resource, err := getResource(resourceID)
+	if err != nil {
+		return nil, err
+	}
+	if resource.OwnerID != uID {
+		return nil, models.ErrForbidden
+	}
+	oID, err := strconv.ParseUint(organizerID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	eID, err := strconv.ParseUint(eventID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var reviewed models.Booking
+	event, err := storage.UpdateBooking(oID, eID, resource.ResourceID, func(booking models.Booking) (models.Booking, error) {
+		booking.Status = models.BookingRejected
+		if approve {
+			booking.Status = models.BookingConfirmed
+		}
+		reviewed = booking
+		return booking, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	webhook.Notify(models.ChangeUpdated, event)
+	return &reviewed, nil
+}
+
+func getResource(resourceID string) (models.Resource, error) {
+	rID, err := strconv.ParseUint(resourceID, 10, 64)
+	if err != nil {
+		return models.Resource{}, err
+	}
+	return storage.GetResource(rID)
+}
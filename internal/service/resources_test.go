@@ -0,0 +1,170 @@
+package service
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestBookResource_Concurrent(t *testing.T) {
+	storage.Clear()
+
+	room, err := CreateResource("9", "Room 1", "room", "", "", "")
+	if err != nil {
+		t.Fatalf("CreateResource() error = %v", err)
+	}
+	roomID := strconv.FormatUint(room.ResourceID, 10)
+
+	const clients = 20
+	var wg sync.WaitGroup
+	errs := make([]error, clients)
+	for i := range clients {
+		wg.Go(func() {
+			_, errs[i] = CreateEvent(strconv.Itoa(i+1), "2024-01-15", "Meeting", "", WithTime("10:00"), WithResources(roomID))
+		})
+	}
+	wg.Wait()
+
+	booked := 0
+	for _, err := range errs {
+		if err == nil {
+			booked++
+		} else if !errors.Is(err, models.ErrResourceBusy) {
+			t.Errorf("CreateEvent() error = %v", err)
+		}
+	}
+	if booked != 1 {
+		t.Errorf("Room booked %d times", booked)
+	}
+}
+
+func TestResourcePeakOverlap(t *testing.T) {
+	storage.Clear()
+
+	projectors, err := CreateResource("9", "Projector", "equipment", "", "2", "")
+	if err != nil {
+		t.Fatalf("CreateResource() error = %v", err)
+	}
+	projectorsID := strconv.FormatUint(projectors.ResourceID, 10)
+
+	// Back to back bookings hold one projector at a time.
+	for _, at := range []string{"10:00", "11:00"} {
+		if _, err = CreateEvent("1", "2024-01-15", "Demo", "", WithTime(at), WithResources(projectorsID)); err != nil {
+			t.Fatalf("Booking at %s error = %v", at, err)
+		}
+	}
+	if _, err = CreateEvent("2", "2024-01-15", "Workshop", "", WithTime("10:00"), WithDuration("120"), WithResources(projectorsID)); err != nil {
+		t.Errorf("Booking over both error = %v", err)
+	}
+	if _, err = CreateEvent("3", "2024-01-15", "Training", "", WithTime("11:30"), WithResources(projectorsID)); !errors.Is(err, models.ErrResourceBusy) {
+		t.Errorf("Third booking at once error = %v, want %v", err, models.ErrResourceBusy)
+	}
+}
+
+func TestResources(t *testing.T) {
+	storage.Clear()
+
+	projectors, err := CreateResource("9", "Projector", "equipment", "", "2", "")
+	if err != nil {
+		t.Fatalf("CreateResource() error = %v", err)
+	}
+	boardroom, err := CreateResource("9", "Boardroom", "room", "3", "", "true")
+	if err != nil {
+		t.Fatalf("CreateResource() error = %v", err)
+	}
+	projectorsID := strconv.FormatUint(projectors.ResourceID, 10)
+	boardroomID := strconv.FormatUint(boardroom.ResourceID, 10)
+
+	for i := 1; i <= 2; i++ {
+		if _, err = CreateEvent(strconv.Itoa(i), "2024-01-15", "Demo", "", WithTime("10:00"), WithResources(projectorsID)); err != nil {
+			t.Fatalf("Booking projector %d error = %v", i, err)
+		}
+	}
+	if _, err = CreateEvent("3", "2024-01-15", "Demo", "", WithTime("10:30"), WithResources(projectorsID)); !errors.Is(err, models.ErrResourceBusy) {
+		t.Errorf("Third projector booking error = %v, want %v", err, models.ErrResourceBusy)
+	}
+	if _, err = CreateEvent("3", "2024-01-15", "Demo", "", WithTime("11:00"), WithResources(projectorsID)); err != nil {
+		t.Errorf("Booking after the others error = %v", err)
+	}
+
+	if _, err = CreateEvent("1", "2024-01-16", "All hands", "", WithAttendees("2,3,4"), WithTime("09:00"), WithResources(boardroomID)); !errors.Is(err, models.ErrCapacityExceeded) {
+		t.Errorf("Booking over capacity error = %v, want %v", err, models.ErrCapacityExceeded)
+	}
+	meeting, err := CreateEvent("1", "2024-01-16", "Board", "", WithAttendees("2"), WithTime("09:00"), WithResources(boardroomID))
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if meeting.Bookings[0].Status != models.BookingPending {
+		t.Errorf("Booking should wait for approval: %+v", meeting.Bookings)
+	}
+	if _, err = CreateEvent("2", "2024-01-16", "Board", "", WithTime("09:30"), WithResources(boardroomID)); !errors.Is(err, models.ErrResourceBusy) {
+		t.Errorf("Pending booking should hold the room, error = %v", err)
+	}
+
+	meetingID := strconv.FormatUint(meeting.EventID, 10)
+	if _, err = ReviewBooking("1", boardroomID, "1", meetingID, true); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("ReviewBooking() by organizer error = %v, want %v", err, models.ErrForbidden)
+	}
+	reviewed, err := ReviewBooking("9", boardroomID, "1", meetingID, false)
+	if err != nil {
+		t.Fatalf("ReviewBooking() error = %v", err)
+	}
+	if reviewed.ResourceID != boardroom.ResourceID || reviewed.Status != models.BookingRejected {
+		t.Errorf("Booking not rejected: %+v", reviewed)
+	}
+	if _, err = CreateEvent("2", "2024-01-16", "Board", "", WithTime("09:30"), WithResources(boardroomID)); err != nil {
+		t.Errorf("Rejected booking should free the room, error = %v", err)
+	}
+
+	review, err := CreateEvent("1", "2024-01-17", "Review", "", WithTime("09:00"), WithResources(boardroomID))
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	reviewID := strconv.FormatUint(review.EventID, 10)
+	if _, err = ReviewBooking("9", boardroomID, "1", reviewID, true); err != nil {
+		t.Fatalf("ReviewBooking() error = %v", err)
+	}
+	renamed, err := UpdateEvent("1", reviewID, "2024-01-17", "Quarterly review", "", WithTime("09:00"))
+	if err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if renamed.Bookings[0].Status != models.BookingConfirmed {
+		t.Errorf("Booking should stay confirmed when the time is kept: %+v", renamed.Bookings)
+	}
+	moved, err := UpdateEvent("1", reviewID, "2024-01-17", "Quarterly review", "", WithTime("09:00"), WithDuration("120"))
+	if err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if moved.Bookings[0].Status != models.BookingPending {
+		t.Errorf("Booking should wait for approval again after a move: %+v", moved.Bookings)
+	}
+
+	availability, err := GetResourceAvailability(projectorsID, "2024-01-15", "2024-01-16")
+	if err != nil {
+		t.Fatalf("GetResourceAvailability() error = %v", err)
+	}
+	if len(availability.Busy) != 1 || availability.Busy[0].End.Hour() != 12 {
+		t.Errorf("Unexpected availability %+v", availability.Busy)
+	}
+
+	free, err := FindResources("2024-01-15T10:00:00Z", "2024-01-15T11:00:00Z", "", "")
+	if err != nil {
+		t.Fatalf("FindResources() error = %v", err)
+	}
+	if len(free) != 1 || free[0].ResourceID != boardroom.ResourceID {
+		t.Errorf("Expected only the boardroom to be free, got %+v", free)
+	}
+	if free, _ = FindResources("2024-01-15T10:00:00Z", "2024-01-15T11:00:00Z", "room", "5"); len(free) != 0 {
+		t.Errorf("Boardroom is too small, got %+v", free)
+	}
+
+	if err = DeleteResource("9", projectorsID); !errors.Is(err, models.ErrResourceInUse) {
+		t.Errorf("DeleteResource() error = %v, want %v", err, models.ErrResourceInUse)
+	}
+	if _, err = CreateResource("9", "", "", "", "", ""); !errors.Is(err, models.ErrNameIsRequired) {
+		t.Errorf("CreateResource() error = %v, want %v", err, models.ErrNameIsRequired)
+	}
+}
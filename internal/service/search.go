@@ -0,0 +1,68 @@
+package service
+
+import (
+	"http-calendar/internal/models"
+	"http-calendar/internal/search"
+	"http-calendar/internal/storage"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultSearchResults = 20
+	maxSearchResults     = 100
+)
+
+// SearchEvents returns the events of userID whose title or description
+// contain every word of query, where words match as prefixes, the most
+// relevant first. from and to are optional dates or RFC 3339 timestamps
+// that bound the time of the events; calendars are filtered with
+// InCalendars. Searching needs read access.
+func SearchEvents(userID, query, from, to, limit string, opts ...QueryOption) ([]models.SearchResult, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if len(search.Tokenize(query)) == 0 {
+		return nil, models.ErrInvalidQuery
+	}
+	var start, end time.Time
+	if from != "" {
+		if start, err = ParseTime(from); err != nil {
+			return nil, models.ErrInvalidRange
+		}
+	}
+	if to != "" {
+		if end, err = ParseTime(to); err != nil || !end.After(start) {
+			return nil, models.ErrInvalidRange
+		}
+	}
+	n := defaultSearchResults
+	if limit != "" {
+		if n, err = strconv.Atoi(limit); err != nil || n <= 0 || n > maxSearchResults {
+			return nil, models.ErrInvalidQuery
+		}
+	}
+
+	q, err := newEventQuery(opts)
+	if err != nil {
+		return nil, err
+	}
+	level, err := access(q.actor, uID, models.AccessRead)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.SearchResult, 0)
+	for _, hit := range storage.SearchEvents(uID, query) {
+		event := hit.Event
+		if !q.match(event) || hidesDetails(event, level) || (from != "" && !event.End().After(start)) || (to != "" && !event.Start().Before(end)) {
+			continue
+		}
+		result = append(result, hit)
+		if len(result) == n {
+			break
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"strconv"
+	"testing"
+)
+
+func TestSearchEvents(t *testing.T) {
+	storage.Clear()
+
+	calendar, err := CreateCalendar("1", "Work", "", "", "")
+	if err != nil {
+		t.Fatalf("CreateCalendar() error = %v", err)
+	}
+	review, err := CreateEvent("1", "2024-01-15", "Budget review", "Numbers for the board")
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	workshop, err := CreateEvent("1", "2024-02-20", "Budgeting workshop", "", InCalendar(strconv.FormatUint(calendar.CalendarID, 10)))
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if _, err = CreateEvent("2", "2024-01-15", "Budget", ""); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+
+	titles := func(results []models.SearchResult, err error) []string {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("SearchEvents() error = %v", err)
+		}
+		result := make([]string, 0, len(results))
+		for _, r := range results {
+			result = append(result, r.Event.Title)
+		}
+		return result
+	}
+
+	assertStrings(t, titles(SearchEvents("1", "budget", "", "", "")), "Budget review", "Budgeting workshop")
+	assertStrings(t, titles(SearchEvents("1", "budg", "2024-02-01", "", "")), "Budgeting workshop")
+	assertStrings(t, titles(SearchEvents("1", "budg", "", "2024-02-01", "")), "Budget review")
+	assertStrings(t, titles(SearchEvents("1", "budg", "", "", "", InCalendars("0"))), "Budget review")
+	assertStrings(t, titles(SearchEvents("1", "budg", "", "", "1")), "Budget review")
+
+	reviewID := strconv.FormatUint(review.EventID, 10)
+	if _, err = UpdateEvent("1", reviewID, "2024-01-15", "Offsite", ""); err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	assertStrings(t, titles(SearchEvents("1", "board", "", "", "")))
+	assertStrings(t, titles(SearchEvents("1", "offsite", "", "", "")), "Offsite")
+
+	if err = DeleteEvent("1", strconv.FormatUint(workshop.EventID, 10)); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+	assertStrings(t, titles(SearchEvents("1", "budgeting", "", "", "")))
+
+	if _, err = SearchEvents("1", "budget", "", "", "", Actor(2)); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("SearchEvents() by stranger error = %v, want %v", err, models.ErrForbidden)
+	}
+	if _, err = SearchEvents("1", "?!", "", "", ""); !errors.Is(err, models.ErrInvalidQuery) {
+		t.Errorf("SearchEvents() error = %v, want %v", err, models.ErrInvalidQuery)
+	}
+}
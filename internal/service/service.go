@@ -4,27 +4,51 @@ import (
 	"errors"
 	"http-calendar/internal/models"
 	"http-calendar/internal/storage"
+	"http-calendar/internal/webhook"
+	"sort"
 	"strconv"
 	"time"
 )
 
 const DateFormat = "2006-01-02"
 
-func CreateEvent(userID, dateStr, title, description string) (*models.Event, error) {
+func CreateEvent(userID, dateStr, title, description string, opts ...EventOption) (*models.Event, error) {
 	uID, date, err := validateAndParse(userID, dateStr, title)
 	if err != nil || errors.Is(err, models.ErrTitleIsRequired) {
 		return nil, err
 	}
 
 	event := models.NewEvent(uID, storage.GetNewEventID(), date, title, description)
-	err = storage.CreateEvent(event)
+	req, err := newEventRequest(event, opts)
 	if err != nil {
 		return nil, err
 	}
+	if _, err = access(req.actor, uID, models.AccessWrite); err != nil {
+		return nil, err
+	}
+	inCalendarZone(event)
+	if err = applyFields(event, req.fields); err != nil {
+		return nil, err
+	}
+	autoDecline(event)
+	if err = prepareBookings(event); err != nil {
+		return nil, err
+	}
+	err = reportConflicts(req, storage.CreateEvent(event, conflictChecks(req)...))
+	if err != nil {
+		return nil, err
+	}
+	webhook.Notify(models.ChangeCreated, *event)
 	return event, nil
 }
 
-func UpdateEvent(userID, eventID, dateStr, title, description string) (*models.Event, error) {
+// maxUpdateAttempts bounds how often an update starts over when the event
+// was written while it was prepared.
+const maxUpdateAttempts = 3
+
+// UpdateEvent sets the date, title and description of an event of userID and
+// applies opts to it. Everything else is kept as stored.
+func UpdateEvent(userID, eventID, dateStr, title, description string, opts ...EventOption) (*models.Event, error) {
 	uID, date, err := validateAndParse(userID, dateStr, title)
 	if err != nil || errors.Is(err, models.ErrTitleIsRequired) {
 		return nil, err
@@ -35,15 +59,59 @@ func UpdateEvent(userID, eventID, dateStr, title, description string) (*models.E
 		return nil, err
 	}
 
-	event := models.NewEvent(uID, eID, date, title, description)
-	err = storage.UpdateEvent(event)
+	for attempt := 1; ; attempt++ {
+		event, level, err := updateEvent(uID, eID, date, title, description, opts)
+		if errors.Is(err, models.ErrEventChanged) && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		webhook.Notify(models.ChangeUpdated, *event)
+		visible, _ := visibleEvent(*event, level)
+		return &visible, nil
+	}
+}
+
+// updateEvent starts from the stored event and writes the update unless the
+// event was changed in the meantime. It returns the event with the access
+// level of the actor.
+func updateEvent(uID, eID uint64, date time.Time, title, description string, opts []EventOption) (*models.Event, string, error) {
+	existing, version, err := storage.GetEventAt(uID, eID)
 	if err != nil {
-		return nil, err
+		// Storage tells that the event does not exist after access was
+		// checked.
+		existing = models.Event{UserID: uID, EventID: eID}
 	}
-	return event, nil
+	event := existing
+	event.Date, event.Title, event.Description = date, title, description
+	req, err := newEventRequest(&event, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	level, err := access(req.actor, uID, models.AccessWrite)
+	if err != nil {
+		return nil, "", err
+	}
+	inCalendarZone(&event)
+	if err = applyFields(&event, req.fields); err != nil {
+		return nil, "", err
+	}
+	autoDecline(&event)
+	resetMovedBookings(&existing, &event)
+	if err = prepareBookings(&event); err != nil {
+		return nil, "", err
+	}
+	err = reportConflicts(req, storage.UpdateEventAt(&event, version, conflictChecks(req)...))
+	if err != nil {
+		return nil, "", err
+	}
+	return &event, level, nil
 }
 
-func DeleteEvent(userID, eventID string) error {
+// DeleteEvent moves an event into the trash of userID, from where it can be
+// restored until the trash retention ends.
+func DeleteEvent(userID, eventID string, opts ...EventOption) error {
 	uID, err := strconv.ParseUint(userID, 10, 64)
 	if err != nil {
 		return err
@@ -52,31 +120,68 @@ func DeleteEvent(userID, eventID string) error {
 	if err != nil {
 		return err
 	}
-	return storage.DeleteEvent(uID, eID)
+	req, err := newEventRequest(&models.Event{}, opts)
+	if err != nil {
+		return err
+	}
+	if _, err = access(req.actor, uID, models.AccessWrite); err != nil {
+		return err
+	}
+	event, err := storage.TrashEvent(uID, eID, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	webhook.Notify(models.ChangeDeleted, event)
+	return nil
 }
 
-func GetEventsForDay(userID, dateStr string) ([]models.Event, error) {
+func GetEventsForDay(userID, dateStr string, opts ...QueryOption) ([]models.Event, error) {
 	uID, date, err := parseUserIDAndDate(userID, dateStr)
 	if err != nil {
 		return nil, err
 	}
-	return storage.GetEventsForDay(uID, date)
+	return readEvents(uID, opts, date, date.AddDate(0, 0, 1), func() ([]models.Event, error) {
+		return storage.GetEventsForDay(uID, date)
+	})
 }
 
-func GetEventsForWeek(userID, dateStr string) ([]models.Event, error) {
+func GetEventsForWeek(userID, dateStr string, opts ...QueryOption) ([]models.Event, error) {
 	uID, date, err := parseUserIDAndDate(userID, dateStr)
 	if err != nil {
 		return nil, err
 	}
-	return storage.GetEventsForWeek(uID, date)
+	return readEvents(uID, opts, date, date.AddDate(0, 0, 7), func() ([]models.Event, error) {
+		return storage.GetEventsForWeek(uID, date)
+	})
 }
 
-func GetEventsForMonth(userID, dateStr string) ([]models.Event, error) {
+func GetEventsForMonth(userID, dateStr string, opts ...QueryOption) ([]models.Event, error) {
 	uID, date, err := parseUserIDAndDate(userID, dateStr)
 	if err != nil {
 		return nil, err
 	}
-	return storage.GetEventsForMonth(uID, date)
+	from := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+	return readEvents(uID, opts, from, from.AddDate(0, 1, 0), func() ([]models.Event, error) {
+		return storage.GetEventsForMonth(uID, date)
+	})
+}
+
+// GetEventsBetween returns the events of userID that overlap the range from
+// to, dates or RFC 3339 timestamps at most 92 days apart.
+func GetEventsBetween(userID, from, to string, opts ...QueryOption) ([]models.Event, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := parseRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return readEvents(uID, opts, start, end, func() ([]models.Event, error) {
+		events := storage.GetEventsBetween(uID, start, end)
+		sort.Slice(events, func(i, j int) bool { return events[i].Start().Before(events[j].Start()) })
+		return events, nil
+	})
 }
 
 func parseUserIDAndDate(userID, dateStr string) (uint64, time.Time, error) {
@@ -1,8 +1,12 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"http-calendar/internal/models"
 	"http-calendar/internal/storage"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 )
@@ -579,3 +583,669 @@ func TestValidateAndParse(t *testing.T) {
 		})
 	}
 }
+
+func TestSync(t *testing.T) {
+	storage.Clear()
+
+	first, err := Sync("1", "")
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if len(first.Events) != 0 {
+		t.Errorf("Expected no events, got %d", len(first.Events))
+	}
+
+	event, err := CreateEvent("1", "2024-01-15", "Meeting", "")
+	if err != nil {
+		t.Fatalf("Failed to create test event: %v", err)
+	}
+	second, err := Sync("1", first.SyncToken)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if len(second.Events) != 1 || second.Events[0].EventID != event.EventID {
+		t.Errorf("Expected created event, got %+v", second.Events)
+	}
+
+	err = DeleteEvent("1", strconv.FormatUint(event.EventID, 10))
+	if err != nil {
+		t.Fatalf("Failed to delete test event: %v", err)
+	}
+	third, err := Sync("1", second.SyncToken)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if len(third.Events) != 0 || len(third.Deleted) != 1 || third.Deleted[0].EventID != event.EventID {
+		t.Errorf("Expected tombstone only, got %+v", third)
+	}
+
+	tests := []struct {
+		name   string
+		userID string
+		token  string
+	}{
+		{name: "garbage token", userID: "1", token: "garbage"},
+		{name: "token of another user", userID: "2", token: second.SyncToken},
+		{name: "token from the future", userID: "1", token: encodeSyncToken(1, 1000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Sync(tt.userID, tt.token)
+			if !errors.Is(err, models.ErrInvalidSyncToken) {
+				t.Errorf("Sync() error = %v, want %v", err, models.ErrInvalidSyncToken)
+			}
+		})
+	}
+
+	// Tokens do not survive a restart of the storage.
+	storage.Clear()
+	if _, err = Sync("1", third.SyncToken); !errors.Is(err, models.ErrInvalidSyncToken) {
+		t.Errorf("Sync() after restart error = %v, want %v", err, models.ErrInvalidSyncToken)
+	}
+}
+
+func TestCreateEventOptions(t *testing.T) {
+	storage.Clear()
+
+	tests := []struct {
+		name       string
+		opts       []EventOption
+		wantErr    error
+		wantTime   string
+		wantAlarms int
+	}{
+		{
+			name:       "time and alarms",
+			opts:       []EventOption{WithTime("10:30"), WithAlarms("15, 60")},
+			wantTime:   "10:30",
+			wantAlarms: 2,
+		},
+		{
+			name: "empty values",
+			opts: []EventOption{WithTime(""), WithAlarms("")},
+		},
+		{
+			name:    "invalid time",
+			opts:    []EventOption{WithTime("25:00")},
+			wantErr: models.ErrInvalidTime,
+		},
+		{
+			name:    "negative alarm",
+			opts:    []EventOption{WithAlarms("-5")},
+			wantErr: models.ErrInvalidAlarm,
+		},
+		{
+			name:    "malformed alarm",
+			opts:    []EventOption{WithAlarms("15,soon")},
+			wantErr: models.ErrInvalidAlarm,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := CreateEvent("1", "2024-01-15", "Meeting", "", tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateEvent() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if event.Time != tt.wantTime {
+				t.Errorf("Event has wrong time = %v, want %v", event.Time, tt.wantTime)
+			}
+			if len(event.Alarms) != tt.wantAlarms {
+				t.Errorf("Event has %d alarms, want %d", len(event.Alarms), tt.wantAlarms)
+			}
+		})
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	storage.Clear()
+
+	user, err := UpdateUser("1", WithEmail("Alice <alice@example.com>"))
+	if err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if user.Email != "alice@example.com" {
+		t.Errorf("User has wrong email = %v", user.Email)
+	}
+	if email, ok := UserEmail(1); !ok || email != "alice@example.com" {
+		t.Errorf("UserEmail() = %v, %v", email, ok)
+	}
+	if _, ok := UserEmail(2); ok {
+		t.Errorf("UserEmail() of unknown user should not be found")
+	}
+
+	user, err = UpdateUser("1", WithTimeZone("Europe/Berlin"), WithDigest(models.PeriodDay, "08:00", DigestEmail, ""))
+	if err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if user.Email != "alice@example.com" || user.TimeZone != "Europe/Berlin" || user.Digest.Period != models.PeriodDay {
+		t.Errorf("Profile not merged: %+v", user)
+	}
+
+	tests := []struct {
+		name    string
+		userID  string
+		opt     UserOption
+		wantErr error
+	}{
+		{name: "invalid email", userID: "1", opt: WithEmail("not an email"), wantErr: models.ErrInvalidEmail},
+		{name: "invalid time zone", userID: "1", opt: WithTimeZone("Mars/Olympus"), wantErr: models.ErrInvalidTimeZone},
+		{name: "invalid period", userID: "1", opt: WithDigest("month", "08:00", DigestEmail, ""), wantErr: models.ErrInvalidDigest},
+		{name: "invalid digest time", userID: "1", opt: WithDigest(models.PeriodDay, "8am", DigestEmail, ""), wantErr: models.ErrInvalidDigest},
+		{name: "webhook without url", userID: "1", opt: WithDigest(models.PeriodDay, "08:00", DigestWebhook, ""), wantErr: models.ErrInvalidURL},
+		{name: "email digest without address", userID: "2", opt: WithDigest(models.PeriodDay, "08:00", DigestEmail, ""), wantErr: models.ErrInvalidEmail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := UpdateUser(tt.userID, tt.opt); !errors.Is(err, tt.wantErr) {
+				t.Errorf("UpdateUser() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err = UpdateUser("invalid"); err == nil {
+		t.Errorf("UpdateUser() expected error for invalid user ID")
+	}
+}
+
+func TestSharing(t *testing.T) {
+	storage.Clear()
+
+	event, err := CreateEvent("1", "2024-01-15", "Salary review", "Confidential", WithTime("10:00"))
+	if err != nil {
+		t.Fatalf("Failed to create test event: %v", err)
+	}
+	eventID := strconv.FormatUint(event.EventID, 10)
+
+	if _, err = GetEventsForDay("1", "2024-01-15", Actor(2)); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("GetEventsForDay() without share error = %v, want %v", err, models.ErrForbidden)
+	}
+
+	for _, s := range []struct{ grantee, level string }{
+		{"2", models.AccessFreeBusy},
+		{"3", models.AccessRead},
+		{"4", models.AccessWrite},
+	} {
+		if _, err = ShareCalendar("1", s.grantee, s.level); err != nil {
+			t.Fatalf("ShareCalendar() error = %v", err)
+		}
+	}
+
+	busy, err := GetEventsForDay("1", "2024-01-15", Actor(2))
+	if err != nil {
+		t.Fatalf("GetEventsForDay() error = %v", err)
+	}
+	if len(busy) != 1 || busy[0].Title != "Busy" || busy[0].Description != "" || busy[0].Time != "10:00" {
+		t.Errorf("Expected a busy placeholder, got %+v", busy)
+	}
+
+	read, err := GetEventsForWeek("1", "2024-01-15", Actor(3))
+	if err != nil {
+		t.Fatalf("GetEventsForWeek() error = %v", err)
+	}
+	if len(read) != 1 || read[0].Title != "Salary review" {
+		t.Errorf("Expected the full event, got %+v", read)
+	}
+	if _, err = UpdateEvent("1", eventID, "2024-01-15", "Renamed", "", Actor(3)); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("UpdateEvent() with read access error = %v, want %v", err, models.ErrForbidden)
+	}
+	if err = DeleteEvent("1", eventID, Actor(2)); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("DeleteEvent() with free/busy access error = %v, want %v", err, models.ErrForbidden)
+	}
+
+	if _, err = UpdateEvent("1", eventID, "2024-01-15", "Renamed", "", Actor(4)); err != nil {
+		t.Errorf("UpdateEvent() with write access error = %v", err)
+	}
+	if _, err = CreateEvent("1", "2024-01-16", "Added by 4", "", Actor(4)); err != nil {
+		t.Errorf("CreateEvent() with write access error = %v", err)
+	}
+
+	shares, err := GetShares("1")
+	if err != nil {
+		t.Fatalf("GetShares() error = %v", err)
+	}
+	if len(shares.Granted) != 3 || len(shares.Received) != 0 {
+		t.Errorf("Unexpected shares %+v", shares)
+	}
+
+	if err = UnshareCalendar("1", "3"); err != nil {
+		t.Fatalf("UnshareCalendar() error = %v", err)
+	}
+	if _, err = GetEventsForDay("1", "2024-01-15", Actor(3)); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("GetEventsForDay() after unshare error = %v, want %v", err, models.ErrForbidden)
+	}
+	if err = UnshareCalendar("1", "3"); !errors.Is(err, models.ErrShareNotFound) {
+		t.Errorf("UnshareCalendar() error = %v, want %v", err, models.ErrShareNotFound)
+	}
+
+	if _, err = ShareCalendar("1", "1", models.AccessRead); !errors.Is(err, models.ErrInvalidShare) {
+		t.Errorf("ShareCalendar() with owner error = %v, want %v", err, models.ErrInvalidShare)
+	}
+	if _, err = ShareCalendar("1", "5", "admin"); !errors.Is(err, models.ErrInvalidAccessLevel) {
+		t.Errorf("ShareCalendar() error = %v, want %v", err, models.ErrInvalidAccessLevel)
+	}
+}
+
+func TestCalendars(t *testing.T) {
+	storage.Clear()
+
+	work, err := CreateCalendar("1", "Work", "#3366ff", "Europe/Berlin", "")
+	if err != nil {
+		t.Fatalf("CreateCalendar() error = %v", err)
+	}
+	workID := strconv.FormatUint(work.CalendarID, 10)
+
+	meeting, err := CreateEvent("1", "2024-01-15", "Meeting", "", InCalendar(workID))
+	if err != nil {
+		t.Fatalf("Failed to create test event: %v", err)
+	}
+	if _, err = CreateEvent("1", "2024-01-15", "Dentist", ""); err != nil {
+		t.Fatalf("Failed to create test event: %v", err)
+	}
+	if _, err = CreateEvent("1", "2024-01-15", "Lost", "", InCalendar("42")); !errors.Is(err, models.ErrCalendarNotFound) {
+		t.Errorf("CreateEvent() in unknown calendar error = %v, want %v", err, models.ErrCalendarNotFound)
+	}
+
+	tests := []struct {
+		name      string
+		calendars string
+		want      int
+	}{
+		{name: "all calendars", want: 2},
+		{name: "work", calendars: workID, want: 1},
+		{name: "default", calendars: "0", want: 1},
+		{name: "both", calendars: "0, " + workID, want: 2},
+		{name: "unknown", calendars: "42", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := GetEventsForDay("1", "2024-01-15", InCalendars(tt.calendars))
+			if err != nil {
+				t.Fatalf("GetEventsForDay() error = %v", err)
+			}
+			if len(events) != tt.want {
+				t.Errorf("Expected %d events, got %d", tt.want, len(events))
+			}
+		})
+	}
+
+	updated, err := UpdateEvent("1", strconv.FormatUint(meeting.EventID, 10), "2024-01-16", "Meeting", "")
+	if err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if updated.CalendarID != work.CalendarID {
+		t.Errorf("UpdateEvent() moved the event to calendar %d", updated.CalendarID)
+	}
+
+	if err = DeleteCalendar("1", workID); !errors.Is(err, models.ErrCalendarNotEmpty) {
+		t.Errorf("DeleteCalendar() error = %v, want %v", err, models.ErrCalendarNotEmpty)
+	}
+	if _, err = UpdateEvent("1", strconv.FormatUint(meeting.EventID, 10), "2024-01-16", "Meeting", "", InCalendar("0")); err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if err = DeleteCalendar("1", workID); err != nil {
+		t.Errorf("DeleteCalendar() error = %v", err)
+	}
+
+	if _, err = CreateCalendar("1", "", "", "", ""); !errors.Is(err, models.ErrNameIsRequired) {
+		t.Errorf("CreateCalendar() error = %v, want %v", err, models.ErrNameIsRequired)
+	}
+	if _, err = CreateCalendar("1", "Personal", "red", "", ""); !errors.Is(err, models.ErrInvalidColor) {
+		t.Errorf("CreateCalendar() error = %v, want %v", err, models.ErrInvalidColor)
+	}
+	if _, err = CreateCalendar("1", "Personal", "", "Mars/Olympus", ""); !errors.Is(err, models.ErrInvalidTimeZone) {
+		t.Errorf("CreateCalendar() error = %v, want %v", err, models.ErrInvalidTimeZone)
+	}
+	if _, err = UpdateCalendar("1", "0", "Default", "", "", ""); !errors.Is(err, models.ErrCalendarNotFound) {
+		t.Errorf("UpdateCalendar() of the default calendar error = %v, want %v", err, models.ErrCalendarNotFound)
+	}
+}
+
+func TestCalendarTimeZone(t *testing.T) {
+	storage.Clear()
+
+	work, err := CreateCalendar("1", "Work", "#3366ff", "Europe/Berlin", models.ConflictWarn)
+	if err != nil {
+		t.Fatalf("CreateCalendar() error = %v", err)
+	}
+	workID := strconv.FormatUint(work.CalendarID, 10)
+
+	// Fields that are not given are kept.
+	renamed, err := UpdateCalendar("1", workID, "Office", "", "", "")
+	if err != nil {
+		t.Fatalf("UpdateCalendar() error = %v", err)
+	}
+	if renamed.Name != "Office" || renamed.Color != "#3366ff" || renamed.TimeZone != "Europe/Berlin" || renamed.ConflictMode != models.ConflictWarn {
+		t.Errorf("UpdateCalendar() = %+v, want only the name changed", renamed)
+	}
+
+	event, err := CreateEvent("1", "2024-01-15", "Standup", "", InCalendar(workID), WithTime("10:00"))
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if want := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC); !event.Start().Equal(want) {
+		t.Errorf("Start() = %v, want %v", event.Start(), want)
+	}
+	events, err := GetEventsForDay("1", "2024-01-15")
+	if err != nil || len(events) != 1 {
+		t.Fatalf("GetEventsForDay() = %d events, error = %v", len(events), err)
+	}
+
+	// Moving the event into the default calendar reads its time in UTC.
+	moved, err := UpdateEvent("1", strconv.FormatUint(event.EventID, 10), "2024-01-15", "Standup", "", InCalendar("0"))
+	if err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if want := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC); !moved.Start().Equal(want) {
+		t.Errorf("Start() = %v, want %v", moved.Start(), want)
+	}
+
+	cleared, err := UpdateCalendar("1", workID, "", "none", "none", "none")
+	if err != nil {
+		t.Fatalf("UpdateCalendar() error = %v", err)
+	}
+	if cleared.Name != "Office" || cleared.Color != "" || cleared.TimeZone != "" || cleared.ConflictMode != "" {
+		t.Errorf("UpdateCalendar() = %+v, want the optional fields cleared", cleared)
+	}
+}
+
+func TestAttendees(t *testing.T) {
+	storage.Clear()
+
+	event, err := CreateEvent("1", "2024-01-15", "Planning", "", WithAttendees("2, Bob <bob@example.com>, 2"))
+	if err != nil {
+		t.Fatalf("Failed to create test event: %v", err)
+	}
+	if len(event.Attendees) != 2 || event.Attendees[0].Status != models.StatusNeedsAction || event.Attendees[1].Email != "bob@example.com" {
+		t.Fatalf("Unexpected attendees %+v", event.Attendees)
+	}
+	eventID := strconv.FormatUint(event.EventID, 10)
+
+	invited, err := GetEventsForWeek("2", "2024-01-15")
+	if err != nil {
+		t.Fatalf("GetEventsForWeek() of invitee error = %v", err)
+	}
+	if len(invited) != 1 || invited[0].EventID != event.EventID || invited[0].UserID != 1 {
+		t.Errorf("Invitee should see the event, got %+v", invited)
+	}
+	if _, err = GetEventsForDay("3", "2024-01-15"); !errors.Is(err, models.ErrUserNotFound) {
+		t.Errorf("GetEventsForDay() of stranger error = %v, want %v", err, models.ErrUserNotFound)
+	}
+
+	replied, err := RSVP("2", "1", eventID, models.StatusAccepted)
+	if err != nil {
+		t.Fatalf("RSVP() error = %v", err)
+	}
+	if replied.Attendees[0].Status != models.StatusAccepted {
+		t.Errorf("RSVP() did not record the status: %+v", replied.Attendees)
+	}
+	if _, err = RSVP("3", "1", eventID, models.StatusAccepted); !errors.Is(err, models.ErrNotInvited) {
+		t.Errorf("RSVP() of stranger error = %v, want %v", err, models.ErrNotInvited)
+	}
+	if _, err = RSVP("2", "1", eventID, "maybe"); !errors.Is(err, models.ErrInvalidStatus) {
+		t.Errorf("RSVP() error = %v, want %v", err, models.ErrInvalidStatus)
+	}
+
+	updated, err := UpdateEvent("1", eventID, "2024-01-15", "Planning", "", WithAttendees("2,3"))
+	if err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if len(updated.Attendees) != 2 || updated.Attendees[0].Status != models.StatusAccepted || updated.Attendees[1].Status != models.StatusNeedsAction {
+		t.Errorf("Statuses not kept on update: %+v", updated.Attendees)
+	}
+	if events, _ := GetEventsForDay("3", "2024-01-15"); len(events) != 1 {
+		t.Errorf("New attendee should see the event, got %+v", events)
+	}
+
+	cleared, err := UpdateEvent("1", eventID, "2024-01-15", "Planning", "", WithAttendees("none"))
+	if err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if len(cleared.Attendees) != 0 {
+		t.Errorf("Expected attendees to be removed, got %+v", cleared.Attendees)
+	}
+	if events, _ := GetEventsForDay("3", "2024-01-15"); len(events) != 0 {
+		t.Errorf("Removed attendee should not see the event, got %+v", events)
+	}
+
+	if _, err = CreateEvent("1", "2024-01-15", "Solo", "", WithAttendees("1")); !errors.Is(err, models.ErrInvalidAttendee) {
+		t.Errorf("CreateEvent() with organizer as attendee error = %v, want %v", err, models.ErrInvalidAttendee)
+	}
+	if _, err = CreateEvent("1", "2024-01-15", "Solo", "", WithAttendees("not an address")); !errors.Is(err, models.ErrInvalidAttendee) {
+		t.Errorf("CreateEvent() error = %v, want %v", err, models.ErrInvalidAttendee)
+	}
+	if _, err = SendInvitations(context.Background(), "1", eventID); !errors.Is(err, models.ErrMailDisabled) {
+		t.Errorf("SendInvitations() error = %v, want %v", err, models.ErrMailDisabled)
+	}
+
+	if err = DeleteEvent("1", eventID); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+	if events, _ := GetEventsForDay("2", "2024-01-15"); len(events) != 0 {
+		t.Errorf("Deleted event still shown to invitee: %+v", events)
+	}
+}
+
+func TestRSVPKeepsConcurrentAnswers(t *testing.T) {
+	storage.Clear()
+
+	event, err := CreateEvent("1", "2024-01-15", "Planning", "", WithAttendees("2,3,4,5,6"))
+	if err != nil {
+		t.Fatalf("Failed to create test event: %v", err)
+	}
+	eventID := strconv.FormatUint(event.EventID, 10)
+
+	answered := make([]bool, 7)
+	var wg sync.WaitGroup
+	for user := 2; user <= 6; user++ {
+		wg.Go(func() {
+			_, err := RSVP(strconv.Itoa(user), "1", eventID, models.StatusAccepted)
+			if err != nil && !errors.Is(err, models.ErrEventChanged) {
+				t.Errorf("RSVP() error = %v", err)
+			}
+			answered[user] = err == nil
+		})
+	}
+	wg.Wait()
+
+	stored, err := storage.GetEvent(1, event.EventID)
+	if err != nil {
+		t.Fatalf("GetEvent() error = %v", err)
+	}
+	for _, attendee := range stored.Attendees {
+		if answered[attendee.UserID] && attendee.Status != models.StatusAccepted {
+			t.Errorf("Answer of user %d was lost", attendee.UserID)
+		}
+	}
+}
+
+func TestFreeBusy(t *testing.T) {
+	storage.Clear()
+
+	for _, e := range []struct {
+		user, date, at, duration, title string
+	}{
+		{"1", "2024-01-15", "09:00", "60", "Standup"},
+		{"1", "2024-01-15", "09:30", "60", "Overlapping"},
+		{"1", "2024-01-15", "10:30", "30", "Touching"},
+		{"1", "2024-01-15", "14:00", "", "Default length"},
+		{"1", "2024-01-16", "", "", "All day"},
+		{"1", "2024-01-20", "09:00", "", "Outside"},
+	} {
+		if _, err := CreateEvent(e.user, e.date, e.title, "", WithTime(e.at), WithDuration(e.duration)); err != nil {
+			t.Fatalf("Failed to create test event: %v", err)
+		}
+	}
+	invite, err := CreateEvent("3", "2024-01-15", "Invite", "", WithTime("12:00"), WithAttendees("2"))
+	if err != nil {
+		t.Fatalf("Failed to create test event: %v", err)
+	}
+
+	result, err := GetFreeBusy("1,2", "2024-01-15", "2024-01-17")
+	if err != nil {
+		t.Fatalf("GetFreeBusy() error = %v", err)
+	}
+	at := func(day, hour, minute int) time.Time { return time.Date(2024, 1, day, hour, minute, 0, 0, time.UTC) }
+	want := []models.Interval{
+		{Start: at(15, 9, 0), End: at(15, 11, 0)},
+		{Start: at(15, 14, 0), End: at(15, 15, 0)},
+		{Start: at(16, 0, 0), End: at(17, 0, 0)},
+	}
+	if len(result.Users) != 2 || len(result.Users[0].Busy) != len(want) {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+	for i, interval := range want {
+		got := result.Users[0].Busy[i]
+		if !got.Start.Equal(interval.Start) || !got.End.Equal(interval.End) {
+			t.Errorf("Busy[%d] = %v - %v, want %v - %v", i, got.Start, got.End, interval.Start, interval.End)
+		}
+	}
+	if busy := result.Users[1].Busy; len(busy) != 1 || !busy[0].Start.Equal(at(15, 12, 0)) {
+		t.Errorf("Invitee should be busy at the invitation, got %+v", busy)
+	}
+
+	if _, err = RSVP("2", "3", strconv.FormatUint(invite.EventID, 10), models.StatusDeclined); err != nil {
+		t.Fatalf("RSVP() error = %v", err)
+	}
+	result, err = GetFreeBusy("2", "2024-01-15T00:00:00Z", "2024-01-16T00:00:00Z")
+	if err != nil {
+		t.Fatalf("GetFreeBusy() error = %v", err)
+	}
+	if len(result.Users[0].Busy) != 0 {
+		t.Errorf("Declined invitation should not be busy, got %+v", result.Users[0].Busy)
+	}
+
+	if _, err = GetFreeBusy("1", "2024-01-15", "2024-01-16", Actor(2)); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("GetFreeBusy() without share error = %v, want %v", err, models.ErrForbidden)
+	}
+	if _, err = ShareCalendar("1", "2", models.AccessFreeBusy); err != nil {
+		t.Fatalf("ShareCalendar() error = %v", err)
+	}
+	if _, err = GetFreeBusy("1", "2024-01-15", "2024-01-16", Actor(2)); err != nil {
+		t.Errorf("GetFreeBusy() with free/busy share error = %v", err)
+	}
+
+	// User 3 acts for user 1 by grant but has no share of user 2.
+	grant := ActorFor(func(owner uint64) (Actor, bool) { return 3, owner != 1 })
+	if _, err = GetFreeBusy("1", "2024-01-15", "2024-01-16", grant); err != nil {
+		t.Errorf("GetFreeBusy() of granted owner error = %v", err)
+	}
+	if _, err = GetFreeBusy("1,2", "2024-01-15", "2024-01-16", grant); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("GetFreeBusy() of unshared user error = %v, want %v", err, models.ErrForbidden)
+	}
+	if _, err = FindSlots("1,2", "30", "2024-01-15", "2024-01-16", grant); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("FindSlots() of unshared user error = %v, want %v", err, models.ErrForbidden)
+	}
+
+	for _, r := range [][2]string{{"2024-01-16", "2024-01-15"}, {"2024-01-01", "2024-12-31"}, {"yesterday", "2024-01-15"}} {
+		if _, err = GetFreeBusy("1", r[0], r[1]); !errors.Is(err, models.ErrInvalidRange) {
+			t.Errorf("GetFreeBusy(%v) error = %v, want %v", r, err, models.ErrInvalidRange)
+		}
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	storage.Clear()
+
+	standup, err := CreateEvent("1", "2024-01-15", "Standup", "", WithTime("09:00"), WithDuration("30"))
+	if err != nil {
+		t.Fatalf("Failed to create test event: %v", err)
+	}
+	if _, err = CreateEvent("2", "2024-01-15", "Invite", "", WithTime("11:00"), WithAttendees("1")); err != nil {
+		t.Fatalf("Failed to create test event: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		at            string
+		mode          string
+		wantErr       error
+		wantConflicts int
+	}{
+		{name: "allow", at: "09:15", mode: models.ConflictAllow},
+		{name: "warn", at: "09:15", mode: models.ConflictWarn, wantConflicts: 1},
+		{name: "reject", at: "09:15", mode: models.ConflictReject, wantErr: models.ErrConflict, wantConflicts: 1},
+		{name: "reject invitation", at: "10:30", mode: models.ConflictReject, wantErr: models.ErrConflict, wantConflicts: 1},
+		{name: "back to back", at: "09:30", mode: models.ConflictReject},
+		{name: "invalid mode", at: "09:15", mode: "maybe", wantErr: models.ErrInvalidConflictMode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conflicts []models.Event
+			event, err := CreateEvent("1", "2024-01-15", "Review", "", WithTime(tt.at), WithDuration("60"), WithConflictMode(tt.mode, &conflicts))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateEvent() error = %v, want %v", err, tt.wantErr)
+			}
+			if event != nil {
+				defer func() { _ = DeleteEvent("1", strconv.FormatUint(event.EventID, 10)) }()
+			}
+			if len(conflicts) != tt.wantConflicts {
+				t.Errorf("Got %d conflicts, want %d", len(conflicts), tt.wantConflicts)
+			}
+			var conflict *models.ConflictError
+			if errors.As(err, &conflict) && len(conflict.Events) != tt.wantConflicts {
+				t.Errorf("ConflictError lists %d events, want %d", len(conflict.Events), tt.wantConflicts)
+			}
+		})
+	}
+
+	standupID := strconv.FormatUint(standup.EventID, 10)
+	_, err = UpdateEvent("1", standupID, "2024-01-15", "Standup", "", WithTime("09:00"), WithDuration("45"), WithConflictMode(models.ConflictReject, nil))
+	if err != nil {
+		t.Errorf("An event should not conflict with itself: %v", err)
+	}
+	_, err = UpdateEvent("1", standupID, "2024-01-15", "Standup", "", WithTime("10:45"), WithDuration("30"), WithConflictMode(models.ConflictReject, nil))
+	if !errors.Is(err, models.ErrConflict) {
+		t.Errorf("UpdateEvent() error = %v, want %v", err, models.ErrConflict)
+	}
+
+	oncall, err := CreateCalendar("1", "On-call", "", "", models.ConflictReject)
+	if err != nil {
+		t.Fatalf("CreateCalendar() error = %v", err)
+	}
+	calendarID := strconv.FormatUint(oncall.CalendarID, 10)
+	if _, err = CreateEvent("1", "2024-01-15", "Page", "", WithTime("09:00"), InCalendar(calendarID)); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("CreateEvent() in rejecting calendar error = %v, want %v", err, models.ErrConflict)
+	}
+	if _, err = CreateEvent("1", "2024-01-15", "Page", "", WithTime("09:00"), InCalendar(calendarID), WithConflictMode(models.ConflictAllow, nil)); err != nil {
+		t.Errorf("Request mode should override the calendar: %v", err)
+	}
+}
+
+func TestConflictsVisibleToActor(t *testing.T) {
+	storage.Clear()
+
+	if _, err := CreateEvent("1", "2024-01-15", "Doctor", "Checkup", WithTime("09:00"), WithVisibility(models.VisibilityPrivate)); err != nil {
+		t.Fatalf("Failed to create test event: %v", err)
+	}
+	if _, err := CreateEvent("2", "2024-01-15", "Invite", "", WithTime("09:00"), WithAttendees("1")); err != nil {
+		t.Fatalf("Failed to create test event: %v", err)
+	}
+	if _, err := ShareCalendar("1", "3", models.AccessWrite); err != nil {
+		t.Fatalf("ShareCalendar() error = %v", err)
+	}
+
+	var conflicts []models.Event
+	_, err := CreateEvent("1", "2024-01-15", "Review", "", WithTime("09:00"), WithConflictMode(models.ConflictReject, &conflicts), Actor(3))
+	var conflict *models.ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("CreateEvent() error = %v, want %v", err, models.ErrConflict)
+	}
+	// The invitation of user 2 is not shared with the writer.
+	for _, events := range [][]models.Event{conflicts, conflict.Events} {
+		if len(events) != 1 || events[0].Title != "Busy" || events[0].Description != "" {
+			t.Errorf("Expected the private event as busy time only, got %+v", events)
+		}
+	}
+
+	_, err = CreateEvent("1", "2024-01-15", "Review", "", WithTime("09:00"), WithConflictMode(models.ConflictWarn, &conflicts))
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if len(conflicts) != 2 {
+		t.Errorf("Owner should see both conflicts, got %+v", conflicts)
+	}
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"http-calendar/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	slotStep         = 15 * time.Minute
+	maxSlotWindow    = 31 * 24 * time.Hour
+	defaultSlots     = 5
+	maxSlots         = 50
+	maxBufferMinutes = 4 * 60
+
+	// Scores of a candidate slot. Preferred days weigh most, then slots that
+	// leave no gap before or after them, and every day later costs a point.
+	preferredDayScore = 50
+	snugScore         = 10
+	dayPenalty        = 1
+)
+
+// SlotOption adjusts a search for meeting slots.
+type SlotOption interface {
+	applySlot(*slotSearch) error
+}
+
+type slotSearch struct {
+	actor     *uint64
+	actorFor  ActorFor
+	location  *time.Location
+	workStart time.Duration
+	workEnd   time.Duration
+	buffer    time.Duration
+	days      map[time.Weekday]bool
+	limit     int
+}
+
+type slotOptionFunc func(*slotSearch) error
+
+func (f slotOptionFunc) applySlot(s *slotSearch) error {
+	return f(s)
+}
+
+func (a Actor) applySlot(s *slotSearch) error {
+	actor := uint64(a)
+	s.actor = &actor
+	return nil
+}
+
+// WithWorkingHours limits slots to the hours between start and end in HH:MM
+// format, where "24:00" is the end of the day. The default is 09:00 to 17:00.
+func WithWorkingHours(start, end string) SlotOption {
+	return slotOptionFunc(func(s *slotSearch) error {
+		if start == "" && end == "" {
+			return nil
+		}
+		from, ok := parseClock(start)
+		if !ok {
+			return models.ErrInvalidTime
+		}
+		to, ok := parseClock(end)
+		if !ok {
+			return models.ErrInvalidTime
+		}
+		if to <= from {
+			return models.ErrInvalidSlotSearch
+		}
+		s.workStart, s.workEnd = from, to
+		return nil
+	})
+}
+
+// InTimeZone interprets working hours and days in the named time zone
+// instead of UTC.
+func InTimeZone(value string) SlotOption {
+	return slotOptionFunc(func(s *slotSearch) error {
+		if value == "" {
+			return nil
+		}
+		loc, err := time.LoadLocation(value)
+		if err != nil {
+			return models.ErrInvalidTimeZone
+		}
+		s.location = loc
+		return nil
+	})
+}
+
+// WithBuffer keeps slots the given number of minutes away from busy time.
+func WithBuffer(value string) SlotOption {
+	return slotOptionFunc(func(s *slotSearch) error {
+		if value == "" {
+			return nil
+		}
+		minutes, err := strconv.Atoi(value)
+		if err != nil || minutes < 0 || minutes > maxBufferMinutes {
+			return models.ErrInvalidSlotSearch
+		}
+		s.buffer = time.Duration(minutes) * time.Minute
+		return nil
+	})
+}
+
+// WithPreferredDays ranks slots on a comma separated list of weekdays, for
+// example "tue,thu", above the others.
+func WithPreferredDays(value string) SlotOption {
+	return slotOptionFunc(func(s *slotSearch) error {
+		if value == "" {
+			return nil
+		}
+		s.days = make(map[time.Weekday]bool)
+		for _, part := range strings.Split(value, ",") {
+			day, ok := parseWeekday(strings.TrimSpace(part))
+			if !ok {
+				return models.ErrInvalidSlotSearch
+			}
+			s.days[day] = true
+		}
+		return nil
+	})
+}
+
+// WithLimit sets how many slots are returned, 5 by default.
+func WithLimit(value string) SlotOption {
+	return slotOptionFunc(func(s *slotSearch) error {
+		if value == "" {
+			return nil
+		}
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxSlots {
+			return models.ErrInvalidSlotSearch
+		}
+		s.limit = limit
+		return nil
+	})
+}
+
+func parseWeekday(value string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(value, day.String()[:3]) || strings.EqualFold(value, day.String()) {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// FindSlots returns the best slots of duration minutes between from and to
+// in which all users of the comma separated userIDs are free and within
+// their own working hours. Slots start at
+// quarter hours, do not overlap each other and are ordered by score, then by
+// start.
+func FindSlots(userIDs, duration, from, to string, opts ...SlotOption) ([]models.Slot, error) {
+	start, end, err := parseRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if end.Sub(start) > maxSlotWindow {
+		return nil, models.ErrInvalidRange
+	}
+	minutes, err := strconv.Atoi(duration)
+	if err != nil || minutes <= 0 || minutes > maxDurationMinutes {
+		return nil, models.ErrInvalidDuration
+	}
+	ids, err := parseUserIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &slotSearch{location: time.UTC, workStart: 9 * time.Hour, workEnd: 17 * time.Hour, limit: defaultSlots}
+	for _, opt := range opts {
+		if err = opt.applySlot(s); err != nil {
+			return nil, err
+		}
+	}
+
+	var busy []models.Interval
+	for _, uID := range ids {
+		if _, err = access(actorOf(s.actor, s.actorFor, uID), uID, models.AccessFreeBusy); err != nil {
+			return nil, err
+		}
+		for _, interval := range busyIntervals(uID, start.Add(-s.buffer), end.Add(s.buffer)) {
+			busy = append(busy, models.Interval{Start: interval.Start.Add(-s.buffer), End: interval.End.Add(s.buffer)})
+		}
+		busy = append(busy, unavailableIntervals(uID, start, end)...)
+	}
+	return s.find(mergeIntervals(busy), start, end, time.Duration(minutes)*time.Minute), nil
+}
+
+func (s *slotSearch) find(busy []models.Interval, from, to time.Time, length time.Duration) []models.Slot {
+	first := from.Truncate(slotStep)
+	if first.Before(from) {
+		first = first.Add(slotStep)
+	}
+	firstDay := startOfDay(from.In(s.location))
+
+	var candidates []models.Slot
+	for start := first; !start.Add(length).After(to); start = start.Add(slotStep) {
+		end := start.Add(length)
+		day := startOfDay(start.In(s.location))
+		workStart, workEnd := day.Add(s.workStart), day.Add(s.workEnd)
+		if start.Before(workStart) || end.After(workEnd) || overlaps(busy, start, end) {
+			continue
+		}
+
+		score := -dayPenalty * int(day.Sub(firstDay).Round(24*time.Hour)/(24*time.Hour))
+		if s.days == nil || s.days[day.Weekday()] {
+			score += preferredDayScore
+		}
+		if start.Equal(workStart) || end.Equal(workEnd) || touches(busy, start, end) {
+			score += snugScore
+		}
+		candidates = append(candidates, models.Slot{Start: start, End: end, Score: score})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].Start.Before(candidates[j].Start)
+	})
+
+	result := make([]models.Slot, 0, s.limit)
+	for _, candidate := range candidates {
+		if len(result) == s.limit {
+			break
+		}
+		if overlapsSlot(result, candidate) {
+			continue
+		}
+		result = append(result, candidate)
+	}
+	return result
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// overlaps reports whether [start, end) overlaps one of the sorted, disjoint
+// intervals.
+func overlaps(intervals []models.Interval, start, end time.Time) bool {
+	i := sort.Search(len(intervals), func(i int) bool { return intervals[i].End.After(start) })
+	return i < len(intervals) && intervals[i].Start.Before(end)
+}
+
+// touches reports whether an interval ends at start or begins at end.
+func touches(intervals []models.Interval, start, end time.Time) bool {
+	i := sort.Search(len(intervals), func(i int) bool { return !intervals[i].End.Before(start) })
+	if i < len(intervals) && intervals[i].End.Equal(start) {
+		return true
+	}
+	j := sort.Search(len(intervals), func(j int) bool { return !intervals[j].Start.Before(end) })
+	return j < len(intervals) && intervals[j].Start.Equal(end)
+}
+
+func overlapsSlot(slots []models.Slot, candidate models.Slot) bool {
+	for _, slot := range slots {
+		if slot.Start.Before(candidate.End) && candidate.Start.Before(slot.End) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// slotFixture fills the week of Monday 2024-01-15:
+//
+//	user 1: Mon 09:00-10:00, Mon 13:00-14:00, Tue all day
+//	user 2: Mon 10:00-11:30, Wed 09:00-17:00
+func slotFixture(t *testing.T) {
+	t.Helper()
+	storage.Clear()
+
+	for _, e := range []struct {
+		user, date, at, duration string
+	}{
+		{"1", "2024-01-15", "09:00", "60"},
+		{"1", "2024-01-15", "13:00", "60"},
+		{"1", "2024-01-16", "", ""},
+		{"2", "2024-01-15", "10:00", "90"},
+		{"2", "2024-01-17", "09:00", "480"},
+	} {
+		if _, err := CreateEvent(e.user, e.date, "Busy", "", WithTime(e.at), WithDuration(e.duration)); err != nil {
+			t.Fatalf("Failed to create test event: %v", err)
+		}
+	}
+}
+
+func TestFindSlots(t *testing.T) {
+	slotFixture(t)
+	at := func(day, hour, minute int) time.Time { return time.Date(2024, 1, day, hour, minute, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name string
+		to   string
+		opts []SlotOption
+		want []time.Time
+	}{
+		{
+			name: "gaps next to busy time first",
+			to:   "2024-01-18",
+			opts: []SlotOption{WithLimit("3")},
+			want: []time.Time{at(15, 11, 30), at(15, 14, 0), at(15, 16, 0)},
+		},
+		{
+			name: "preferred days",
+			to:   "2024-01-19",
+			opts: []SlotOption{WithPreferredDays("thu"), WithLimit("3")},
+			want: []time.Time{at(18, 9, 0), at(18, 16, 0), at(18, 10, 0)},
+		},
+		{
+			name: "buffer",
+			to:   "2024-01-16",
+			opts: []SlotOption{WithBuffer("15"), WithLimit("1")},
+			want: []time.Time{at(15, 11, 45)},
+		},
+		{
+			name: "working hours in time zone",
+			to:   "2024-01-16",
+			opts: []SlotOption{InTimeZone("America/New_York"), WithLimit("1")},
+			want: []time.Time{at(15, 14, 0)},
+		},
+		{
+			name: "no room",
+			to:   "2024-01-16",
+			opts: []SlotOption{WithWorkingHours("09:00", "11:00")},
+			want: []time.Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slots, err := FindSlots("1,2", "60", "2024-01-15", tt.to, tt.opts...)
+			if err != nil {
+				t.Fatalf("FindSlots() error = %v", err)
+			}
+			got := make([]time.Time, 0, len(slots))
+			for _, slot := range slots {
+				got = append(got, slot.Start)
+				if slot.End.Sub(slot.Start) != time.Hour {
+					t.Errorf("Slot %v lasts %v", slot.Start, slot.End.Sub(slot.Start))
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindSlots() = %v, want %v", got, tt.want)
+			}
+
+			again, _ := FindSlots("1,2", "60", "2024-01-15", tt.to, tt.opts...)
+			if !reflect.DeepEqual(slots, again) {
+				t.Errorf("FindSlots() is not deterministic: %v and %v", slots, again)
+			}
+		})
+	}
+}
+
+func TestFindSlots_Invalid(t *testing.T) {
+	slotFixture(t)
+
+	tests := []struct {
+		name     string
+		duration string
+		to       string
+		opts     []SlotOption
+		wantErr  error
+	}{
+		{name: "duration", duration: "0", to: "2024-01-16", wantErr: models.ErrInvalidDuration},
+		{name: "window", duration: "30", to: "2024-03-01", wantErr: models.ErrInvalidRange},
+		{name: "days", duration: "30", to: "2024-01-16", opts: []SlotOption{WithPreferredDays("someday")}, wantErr: models.ErrInvalidSlotSearch},
+		{name: "working hours", duration: "30", to: "2024-01-16", opts: []SlotOption{WithWorkingHours("17:00", "09:00")}, wantErr: models.ErrInvalidSlotSearch},
+		{name: "limit", duration: "30", to: "2024-01-16", opts: []SlotOption{WithLimit("1000")}, wantErr: models.ErrInvalidSlotSearch},
+		{name: "not shared", duration: "30", to: "2024-01-16", opts: []SlotOption{Actor(3)}, wantErr: models.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FindSlots("1,2", tt.duration, "2024-01-15", tt.to, tt.opts...); !errors.Is(err, tt.wantErr) {
+				t.Errorf("FindSlots() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import "http-calendar/internal/models"
+
+// WithStatus marks the event as tentative, confirmed or cancelled. Cancelled
+// events no longer block time.
+func WithStatus(value string) EventOption {
+	return eventOptionFunc(func(r *eventRequest) error {
+		switch value {
+		case "":
+			return nil
+		case models.EventTentative, models.EventConfirmed, models.EventCancelled:
+			r.event.Status = value
+			return nil
+		}
+		return models.ErrInvalidEventStatus
+	})
+}
+
+// WithVisibility sets what users the calendar is shared with see of the
+// event: everything ("public"), only busy time ("private") or the title and
+// time ("confidential").
+func WithVisibility(value string) EventOption {
+	return eventOptionFunc(func(r *eventRequest) error {
+		switch value {
+		case "":
+			return nil
+		case models.VisibilityPublic, models.VisibilityPrivate, models.VisibilityConfidential:
+			r.event.Visibility = value
+			return nil
+		}
+		return models.ErrInvalidVisibility
+	})
+}
+
+// WithTransparency marks the event as busy ("opaque") or free
+// ("transparent") time. Free events do not count in free/busy and conflict
+// checks.
+func WithTransparency(value string) EventOption {
+	return eventOptionFunc(func(r *eventRequest) error {
+		switch value {
+		case "":
+			return nil
+		case models.TransparencyOpaque, models.TransparencyTransparent:
+			r.event.Transparency = value
+			return nil
+		}
+		return models.ErrInvalidTransparency
+	})
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestVisibility(t *testing.T) {
+	storage.Clear()
+
+	if _, err := CreateEvent("1", "2024-01-15", "Team sync", "Agenda", WithTime("09:00"), WithTags("team")); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if _, err := CreateEvent("1", "2024-01-15", "Doctor", "Checkup", WithTime("11:00"), WithTags("health"),
+		WithVisibility(models.VisibilityPrivate)); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if _, err := CreateEvent("1", "2024-01-15", "Salary review", "Numbers", WithTime("14:00"),
+		WithVisibility(models.VisibilityConfidential)); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if _, err := CreateEvent("1", "2024-01-15", "Focus time", "", WithTime("16:00"),
+		WithTransparency(models.TransparencyTransparent)); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if _, err := ShareCalendar("1", "2", models.AccessRead); err != nil {
+		t.Fatalf("ShareCalendar() error = %v", err)
+	}
+	if _, err := ShareCalendar("1", "3", models.AccessFreeBusy); err != nil {
+		t.Fatalf("ShareCalendar() error = %v", err)
+	}
+
+	summary := func(events []models.Event, err error) []string {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("GetEventsForDay() error = %v", err)
+		}
+		result := make([]string, 0, len(events))
+		for _, event := range events {
+			result = append(result, event.Time+" "+event.Title+" "+event.Description)
+		}
+		// Events of a day come in no particular order.
+		slices.Sort(result)
+		return result
+	}
+
+	assertStrings(t, summary(GetEventsForDay("1", "2024-01-15")),
+		"09:00 Team sync Agenda", "11:00 Doctor Checkup", "14:00 Salary review Numbers", "16:00 Focus time ")
+	assertStrings(t, summary(GetEventsForDay("1", "2024-01-15", Actor(2))),
+		"09:00 Team sync Agenda", "11:00 Busy ", "14:00 Salary review ", "16:00 Focus time ")
+	assertStrings(t, summary(GetEventsForDay("1", "2024-01-15", Actor(3))),
+		"09:00 Busy ", "11:00 Busy ", "14:00 Busy ")
+	assertStrings(t, summary(GetEventsForDay("1", "2024-01-15", Actor(2), TaggedWith("health", ""))))
+
+	results, err := SearchEvents("1", "checkup", "", "", "", Actor(2))
+	if err != nil || len(results) != 0 {
+		t.Errorf("Search reveals private events: %+v, error = %v", results, err)
+	}
+}
+
+func TestVisibilityOfUpdates(t *testing.T) {
+	storage.Clear()
+
+	event, err := CreateEvent("1", "2024-01-15", "Doctor", "Checkup", WithTime("11:00"),
+		WithLocation("HQ room 5", "", "", ""), WithAttendees("bob@example.com"),
+		WithVisibility(models.VisibilityPrivate))
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if _, err = ShareCalendar("1", "2", models.AccessWrite); err != nil {
+		t.Fatalf("ShareCalendar() error = %v", err)
+	}
+
+	updated, err := UpdateEvent("1", strconv.FormatUint(event.EventID, 10), "2024-01-15", "Doctor", "Checkup", WithTime("12:00"), Actor(2))
+	if err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if updated.Title != "Busy" || updated.Location != nil || len(updated.Attendees) != 0 || updated.Time != "12:00" {
+		t.Errorf("UpdateEvent() reveals the private event to a writer: %+v", updated)
+	}
+
+	stored, err := storage.GetEvent(1, event.EventID)
+	if err != nil {
+		t.Fatalf("GetEvent() error = %v", err)
+	}
+	if stored.Location == nil || len(stored.Attendees) != 1 || stored.Time != "12:00" {
+		t.Errorf("UpdateEvent() lost details: %+v", stored)
+	}
+}
+
+func TestTransparency(t *testing.T) {
+	storage.Clear()
+
+	focus, err := CreateEvent("1", "2024-01-15", "Focus time", "", WithTime("09:00"), WithDuration("120"),
+		WithTransparency(models.TransparencyTransparent))
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if _, err = CreateEvent("1", "2024-01-15", "Cancelled", "", WithTime("13:00"), WithStatus(models.EventCancelled)); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if _, err = CreateEvent("1", "2024-01-15", "Lunch", "", WithTime("12:00"), WithStatus(models.EventTentative)); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+
+	result, err := GetFreeBusy("1", "2024-01-15", "2024-01-16")
+	if err != nil {
+		t.Fatalf("GetFreeBusy() error = %v", err)
+	}
+	busy := result.Users[0].Busy
+	if len(busy) != 1 || busy[0].Start.Hour() != 12 || busy[0].End.Hour() != 13 {
+		t.Errorf("Expected only lunch to be busy, got %+v", busy)
+	}
+
+	if _, err = CreateEvent("1", "2024-01-15", "Review", "", WithTime("09:30"), WithConflictMode(models.ConflictReject, nil)); err != nil {
+		t.Errorf("Free time should not conflict, error = %v", err)
+	}
+	var conflicts []models.Event
+	if _, err = CreateEvent("1", "2024-01-15", "Walk", "", WithTime("12:30"), WithTransparency(models.TransparencyTransparent),
+		WithConflictMode(models.ConflictWarn, &conflicts)); err != nil || len(conflicts) != 0 {
+		t.Errorf("Free events should not conflict, got %+v, error = %v", conflicts, err)
+	}
+
+	updated, err := UpdateEvent("1", strconv.FormatUint(focus.EventID, 10), "2024-01-15", "Focus time", "")
+	if err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if updated.Transparency != models.TransparencyTransparent {
+		t.Errorf("Update should keep the transparency, got %q", updated.Transparency)
+	}
+
+	for _, tt := range []struct {
+		name string
+		opt  EventOption
+		want error
+	}{
+		{"status", WithStatus("done"), models.ErrInvalidEventStatus},
+		{"visibility", WithVisibility("secret"), models.ErrInvalidVisibility},
+		{"transparency", WithTransparency("free"), models.ErrInvalidTransparency},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CreateEvent("1", "2024-01-15", "Event", "", tt.opt); !errors.Is(err, tt.want) {
+				t.Errorf("CreateEvent() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
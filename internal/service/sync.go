@@ -0,0 +1,79 @@
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"strconv"
+	"strings"
+)
+
+const syncTokenVersion = "v2"
+
+// Sync returns what changed for userID since the state described by
+// syncToken. An empty token requests a full sync.
+func Sync(userID, syncToken string, opts ...QueryOption) (*models.SyncResult, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	level, err := queryAccess(uID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var since uint64
+	if syncToken != "" {
+		since, err = decodeSyncToken(uID, syncToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	events, deleted, seq := storage.GetChangesSince(uID, since)
+	// Tombstones pruned after the read did not affect it, so the horizon is
+	// checked last. Clients behind it may have missed deletions and have to
+	// start over with a full sync.
+	if since > seq || (since > 0 && since < storage.SyncHorizon(uID)) {
+		return nil, models.ErrInvalidSyncToken
+	}
+
+	return &models.SyncResult{
+		SyncToken: encodeSyncToken(uID, seq),
+		Events:    visibleEvents(events, level),
+		Deleted:   deleted,
+	}, nil
+}
+
+// Sync tokens carry the epoch of the storage, so that tokens issued before
+// a restart are rejected.
+func encodeSyncToken(userID, seq uint64) string {
+	raw := fmt.Sprintf("%s:%d:%d:%d", syncTokenVersion, userID, storage.Epoch(), seq)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSyncToken(userID uint64, token string) (uint64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, models.ErrInvalidSyncToken
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 4 || parts[0] != syncTokenVersion {
+		return 0, models.ErrInvalidSyncToken
+	}
+	tokenUserID, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil || tokenUserID != userID {
+		return 0, models.ErrInvalidSyncToken
+	}
+	epoch, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || epoch != storage.Epoch() {
+		return 0, models.ErrInvalidSyncToken
+	}
+	seq, err := strconv.ParseUint(parts[3], 10, 64)
+	if err != nil {
+		return 0, models.ErrInvalidSyncToken
+	}
+	return seq, nil
+}
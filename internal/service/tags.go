@@ -0,0 +1,127 @@
+package service
+
+import (
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxLabelLength = 64
+
+// WithTags sets the tags of the event from a comma separated list, for
+// example "urgent,customer". Tags are stored in lower case. Value "none"
+// removes them; an empty value keeps the tags of an updated event.
+func WithTags(value string) EventOption {
+	return eventOptionFunc(func(r *eventRequest) error {
+		switch value {
+		case "":
+			return nil
+		case "none":
+			r.event.Tags = nil
+			return nil
+		}
+		tags, err := parseTags(value)
+		if err != nil {
+			return err
+		}
+		r.event.Tags = tags
+		return nil
+	})
+}
+
+// WithCategory puts the event into a category such as "Meeting". Value
+// "none" removes it; an empty value keeps the category of an updated event.
+func WithCategory(value string) EventOption {
+	return eventOptionFunc(func(r *eventRequest) error {
+		switch value {
+		case "":
+			return nil
+		case "none":
+			r.event.Category = ""
+			return nil
+		}
+		category := strings.TrimSpace(value)
+		if category == "" || utf8.RuneCountInString(category) > maxLabelLength {
+			return models.ErrInvalidCategory
+		}
+		r.event.Category = category
+		return nil
+	})
+}
+
+// TaggedWith limits a read to events with any or, with mode "all", all of a
+// comma separated list of tags.
+func TaggedWith(value, mode string) QueryOption {
+	return queryOptionFunc(func(q *eventQuery) error {
+		switch mode {
+		case "", models.TagModeAny, models.TagModeAll:
+		default:
+			return models.ErrInvalidTagMode
+		}
+		if value == "" {
+			return nil
+		}
+		tags, err := parseTags(value)
+		if err != nil {
+			return err
+		}
+		q.tags, q.allTags = tags, mode == models.TagModeAll
+		return nil
+	})
+}
+
+// InCategory limits a read to the events of a category, ignoring case.
+func InCategory(value string) QueryOption {
+	return queryOptionFunc(func(q *eventQuery) error {
+		q.category = strings.TrimSpace(value)
+		return nil
+	})
+}
+
+// TitleContains limits a read to events whose title contains value,
+// ignoring case.
+func TitleContains(value string) QueryOption {
+	return queryOptionFunc(func(q *eventQuery) error {
+		q.title = strings.ToLower(strings.TrimSpace(value))
+		return nil
+	})
+}
+
+func parseTags(value string) ([]string, error) {
+	var tags []string
+	for _, part := range strings.Split(value, ",") {
+		tag := strings.ToLower(strings.TrimSpace(part))
+		if tag == "" || utf8.RuneCountInString(tag) > maxLabelLength {
+			return nil, models.ErrInvalidTag
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// GetTags returns the tags used by the events of userID with the number of
+// events carrying each, the most used first. Events whose details are
+// hidden from the actor are not counted.
+func GetTags(userID string, opts ...QueryOption) ([]models.TagCount, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	q, err := newEventQuery(opts)
+	if err != nil {
+		return nil, err
+	}
+	level, err := access(q.actor, uID, models.AccessRead)
+	if err != nil {
+		return nil, err
+	}
+	if level == accessOwner {
+		return storage.GetTagCounts(uID, nil), nil
+	}
+	return storage.GetTagCounts(uID, func(event models.Event) bool { return !hidesDetails(event, level) }), nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	storage.Clear()
+
+	kickoff, err := CreateEvent("1", "2024-01-15", "Project kickoff", "", WithTags("Customer, urgent"), WithCategory("Meeting"))
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if !slices.Equal(kickoff.Tags, []string{"customer", "urgent"}) {
+		t.Errorf("Tags = %v", kickoff.Tags)
+	}
+	if _, err = CreateEvent("1", "2024-01-16", "Support call", "", WithTags("customer"), WithCategory("Call")); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if _, err = CreateEvent("1", "2024-01-17", "Dentist", ""); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+
+	titles := func(opts ...QueryOption) []string {
+		t.Helper()
+		events, err := GetEventsBetween("1", "2024-01-15", "2024-01-22", opts...)
+		if err != nil {
+			t.Fatalf("GetEventsBetween() error = %v", err)
+		}
+		result := make([]string, 0, len(events))
+		for _, event := range events {
+			result = append(result, event.Title)
+		}
+		return result
+	}
+
+	assertStrings(t, titles(), "Project kickoff", "Support call", "Dentist")
+	assertStrings(t, titles(TaggedWith("urgent,customer", "")), "Project kickoff", "Support call")
+	assertStrings(t, titles(TaggedWith("urgent,customer", models.TagModeAll)), "Project kickoff")
+	assertStrings(t, titles(InCategory("call")), "Support call")
+	assertStrings(t, titles(TitleContains("KICK")), "Project kickoff")
+	assertStrings(t, titles(TaggedWith("customer", ""), TitleContains("call")), "Support call")
+
+	assertTags := func(want ...models.TagCount) {
+		t.Helper()
+		got, err := GetTags("1")
+		if err != nil {
+			t.Fatalf("GetTags() error = %v", err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("GetTags() = %v, want %v", got, want)
+		}
+	}
+	assertTags(models.TagCount{Tag: "customer", Count: 2}, models.TagCount{Tag: "urgent", Count: 1})
+
+	kickoffID := strconv.FormatUint(kickoff.EventID, 10)
+	updated, err := UpdateEvent("1", kickoffID, "2024-01-15", "Project kickoff", "")
+	if err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if len(updated.Tags) != 2 || updated.Category != "Meeting" {
+		t.Errorf("Update without tags should keep them: %+v", updated)
+	}
+	if _, err = UpdateEvent("1", kickoffID, "2024-01-15", "Project kickoff", "", WithTags("internal")); err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	assertTags(models.TagCount{Tag: "customer", Count: 1}, models.TagCount{Tag: "internal", Count: 1})
+	if err = DeleteEvent("1", kickoffID); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+	assertTags(models.TagCount{Tag: "customer", Count: 1})
+
+	if _, err = ShareCalendar("1", "2", models.AccessFreeBusy); err != nil {
+		t.Fatalf("ShareCalendar() error = %v", err)
+	}
+	if _, err = GetEventsBetween("1", "2024-01-15", "2024-01-22", Actor(2), TaggedWith("customer", "")); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("Filtering with free/busy access error = %v, want %v", err, models.ErrForbidden)
+	}
+
+	if _, err = CreateEvent("1", "2024-01-18", "Therapy", "", WithTags("health"), WithVisibility(models.VisibilityPrivate)); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if _, err = ShareCalendar("1", "3", models.AccessRead); err != nil {
+		t.Fatalf("ShareCalendar() error = %v", err)
+	}
+	shared, err := GetTags("1", Actor(3))
+	if err != nil {
+		t.Fatalf("GetTags() error = %v", err)
+	}
+	if want := []models.TagCount{{Tag: "customer", Count: 1}}; !slices.Equal(shared, want) {
+		t.Errorf("GetTags() of reader = %v, want %v", shared, want)
+	}
+	assertTags(models.TagCount{Tag: "customer", Count: 1}, models.TagCount{Tag: "health", Count: 1})
+
+	for _, tt := range []struct {
+		name string
+		opt  EventOption
+		want error
+	}{
+		{"empty tag", WithTags("a,,b"), models.ErrInvalidTag},
+		{"blank category", WithCategory(" "), models.ErrInvalidCategory},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CreateEvent("1", "2024-01-15", "Event", "", tt.opt); !errors.Is(err, tt.want) {
+				t.Errorf("CreateEvent() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if _, err = GetEventsBetween("1", "2024-01-15", "2024-01-22", TaggedWith("a", "some")); !errors.Is(err, models.ErrInvalidTagMode) {
+		t.Errorf("GetEventsBetween() error = %v, want %v", err, models.ErrInvalidTagMode)
+	}
+}
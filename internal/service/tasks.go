@@ -0,0 +1,147 @@
+package service
+
+import (
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"strconv"
+	"time"
+)
+
+// IncludeTasks stores the tasks of the owner due within the range of a read
+// of events in tasks. Viewers with free/busy access get none.
+func IncludeTasks(tasks *[]models.Task) QueryOption {
+	return queryOptionFunc(func(q *eventQuery) error {
+		q.tasks = tasks
+		return nil
+	})
+}
+
+// CreateTask adds a task to a calendar of userID. due is a date or an RFC
+// 3339 timestamp and priority ranges from 1, the highest, to 9; both are
+// optional.
+func CreateTask(userID, title, description, due, priority, calendarID string, opts ...EventOption) (*models.Task, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if title == "" {
+		return nil, models.ErrTitleIsRequired
+	}
+
+	task := &models.Task{UserID: uID, TaskID: storage.GetNewEventID(), Title: title, Description: description}
+	if due != "" {
+		t, err := ParseTime(due)
+		if err != nil {
+			return nil, models.ErrInvalidDue
+		}
+		task.Due = &t
+	}
+	if priority != "" {
+		if task.Priority, err = strconv.Atoi(priority); err != nil || task.Priority < 0 || task.Priority > 9 {
+			return nil, models.ErrInvalidPriority
+		}
+	}
+	if calendarID != "" {
+		if task.CalendarID, err = strconv.ParseUint(calendarID, 10, 64); err != nil {
+			return nil, models.ErrCalendarNotFound
+		}
+	}
+
+	if err = checkWrite(uID, opts); err != nil {
+		return nil, err
+	}
+	if err = storage.CreateTask(task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// CompleteTask marks a task as completed now.
+func CompleteTask(userID, taskID string, opts ...EventOption) (*models.Task, error) {
+	return setCompleted(userID, taskID, true, opts)
+}
+
+// ReopenTask marks a completed task as open again.
+func ReopenTask(userID, taskID string, opts ...EventOption) (*models.Task, error) {
+	return setCompleted(userID, taskID, false, opts)
+}
+
+func setCompleted(userID, taskID string, completed bool, opts []EventOption) (*models.Task, error) {
+	uID, tID, err := parseTaskID(userID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkWrite(uID, opts); err != nil {
+		return nil, err
+	}
+
+	task, err := storage.GetTask(uID, tID)
+	if err != nil {
+		return nil, err
+	}
+	task.Completed, task.CompletedAt = completed, nil
+	if completed {
+		now := time.Now().UTC()
+		task.CompletedAt = &now
+	}
+	if err = storage.UpdateTask(&task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func DeleteTask(userID, taskID string, opts ...EventOption) error {
+	uID, tID, err := parseTaskID(userID, taskID)
+	if err != nil {
+		return err
+	}
+	if err = checkWrite(uID, opts); err != nil {
+		return err
+	}
+	return storage.DeleteTask(uID, tID)
+}
+
+// GetTasks returns all tasks of userID, open ones only unless all is true.
+func GetTasks(userID string, all bool, opts ...QueryOption) ([]models.Task, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	q, err := newEventQuery(opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = access(q.actor, uID, models.AccessRead); err != nil {
+		return nil, err
+	}
+
+	result := make([]models.Task, 0)
+	for _, task := range storage.GetTasks(uID) {
+		if (all || !task.Completed) && (q.calendars == nil || q.calendars[task.CalendarID]) {
+			result = append(result, task)
+		}
+	}
+	return result, nil
+}
+
+func parseTaskID(userID, taskID string) (uint64, uint64, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	tID, err := strconv.ParseUint(taskID, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uID, tID, nil
+}
+
+// checkWrite checks that the actor of opts may change the calendar of owner.
+func checkWrite(owner uint64, opts []EventOption) error {
+	req, err := newEventRequest(&models.Event{}, opts)
+	if err != nil {
+		return err
+	}
+	_, err = access(req.actor, owner, models.AccessWrite)
+	return err
+}
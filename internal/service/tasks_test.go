@@ -0,0 +1,91 @@
+package service
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"strconv"
+	"testing"
+)
+
+func TestTasks(t *testing.T) {
+	storage.Clear()
+
+	report, err := CreateTask("1", "Quarterly report", "", "2024-01-17T17:00:00Z", "1", "")
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	if _, err = CreateTask("1", "Someday", "", "", "", ""); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	var tasks []models.Task
+	events, err := GetEventsForWeek("1", "2024-01-15", IncludeTasks(&tasks))
+	if err != nil {
+		t.Fatalf("GetEventsForWeek() error = %v", err)
+	}
+	if len(events) != 0 || len(tasks) != 1 || tasks[0].TaskID != report.TaskID {
+		t.Errorf("Expected only the report in the week, got %+v and %+v", events, tasks)
+	}
+	if _, err = GetEventsForDay("1", "2024-01-15", IncludeTasks(&tasks)); err != nil {
+		t.Fatalf("GetEventsForDay() error = %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Errorf("Expected no tasks due on Monday, got %+v", tasks)
+	}
+
+	completed, err := CompleteTask("1", "0")
+	if !errors.Is(err, models.ErrTaskNotFound) {
+		t.Errorf("CompleteTask() error = %v, want %v", err, models.ErrTaskNotFound)
+	}
+	taskID := strconv.FormatUint(report.TaskID, 10)
+	if completed, err = CompleteTask("1", taskID); err != nil {
+		t.Fatalf("CompleteTask() error = %v", err)
+	}
+	if !completed.Completed || completed.CompletedAt == nil {
+		t.Errorf("Task not completed: %+v", completed)
+	}
+	open, err := GetTasks("1", false)
+	if err != nil {
+		t.Fatalf("GetTasks() error = %v", err)
+	}
+	if len(open) != 1 || open[0].Title != "Someday" {
+		t.Errorf("Expected only the open task, got %+v", open)
+	}
+
+	reopened, err := ReopenTask("1", taskID)
+	if err != nil {
+		t.Fatalf("ReopenTask() error = %v", err)
+	}
+	if reopened.Completed || reopened.CompletedAt != nil {
+		t.Errorf("Task not reopened: %+v", reopened)
+	}
+	all, _ := GetTasks("1", true)
+	if len(all) != 2 || all[0].TaskID != report.TaskID {
+		t.Errorf("Expected tasks ordered by due date, got %+v", all)
+	}
+
+	if _, err = ShareCalendar("1", "2", models.AccessFreeBusy); err != nil {
+		t.Fatalf("ShareCalendar() error = %v", err)
+	}
+	if _, err = GetEventsForWeek("1", "2024-01-15", Actor(2), IncludeTasks(&tasks)); err != nil || len(tasks) != 0 {
+		t.Errorf("Free/busy viewer sees tasks %+v, error = %v", tasks, err)
+	}
+	if _, err = CompleteTask("1", taskID, Actor(2)); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("CompleteTask() by viewer error = %v, want %v", err, models.ErrForbidden)
+	}
+
+	for _, tt := range []struct {
+		name, due, priority string
+		want                error
+	}{
+		{"invalid due", "soon", "", models.ErrInvalidDue},
+		{"priority too low", "", "10", models.ErrInvalidPriority},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CreateTask("1", "Task", "", tt.due, tt.priority, ""); !errors.Is(err, tt.want) {
+				t.Errorf("CreateTask() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
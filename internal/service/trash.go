@@ -0,0 +1,115 @@
+package service
+
+import (
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"http-calendar/internal/webhook"
+	"log"
+	"strconv"
+	"time"
+)
+
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+var trashRetention = defaultTrashRetention
+
+// SetTrashRetention sets how long deleted events stay in the trash. Values
+// that are not positive keep the default of 30 days.
+func SetTrashRetention(d time.Duration) {
+	if d <= 0 {
+		d = defaultTrashRetention
+	}
+	trashRetention = d
+}
+
+// GetTrash returns the deleted events of userID that can still be restored,
+// the latest deleted first, as the actor sees them. It needs write access.
+func GetTrash(userID string, opts ...QueryOption) ([]models.TrashedEvent, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	q, err := newEventQuery(opts)
+	if err != nil {
+		return nil, err
+	}
+	level, err := access(q.actor, uID, models.AccessWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	trash := storage.GetTrash(uID)
+	result := trash[:0]
+	for _, trashed := range trash {
+		visible, ok := visibleEvent(trashed.Event, level)
+		if !ok {
+			continue
+		}
+		trashed.Event = visible
+		trashed.PurgeAt = trashed.DeletedAt.Add(trashRetention)
+		result = append(result, trashed)
+	}
+	return result, nil
+}
+
+// RestoreEvent moves a deleted event of userID back out of the trash. It
+// needs write access and passes the checks of a new event, against the
+// current schema and conflict mode of its calendar. Events whose calendar
+// was deleted meanwhile return to the default calendar.
+func RestoreEvent(userID, eventID string, opts ...EventOption) (*models.Event, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	eID, err := strconv.ParseUint(eventID, 10, 64)
+	if err != nil {
+		return nil, models.ErrNotInTrash
+	}
+	req, err := newEventRequest(&models.Event{UserID: uID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	level, err := access(req.actor, uID, models.AccessWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	trashed, err := storage.GetTrashedEvent(uID, eID)
+	if err != nil {
+		return nil, err
+	}
+	event := trashed.Event
+	req.event = &event
+	if _, err = storage.GetCalendar(uID, event.CalendarID); err != nil {
+		event.CalendarID = models.DefaultCalendarID
+	}
+	inCalendarZone(&event)
+	if err = applyFields(&event, nil); err != nil {
+		return nil, err
+	}
+	autoDecline(&event)
+	if err = prepareBookings(&event); err != nil {
+		return nil, err
+	}
+	err = reportConflicts(req, storage.RestoreEvent(&event, conflictChecks(req)...))
+	if err != nil {
+		return nil, err
+	}
+	webhook.Notify(models.ChangeCreated, event)
+	visible, _ := visibleEvent(event, level)
+	return &visible, nil
+}
+
+// PurgeTrash removes the events that have been in the trash longer than the
+// retention at now for good, together with attachments no other event
+// has. It returns the number of purged events.
+func PurgeTrash(now time.Time) int {
+	purged := storage.PurgeTrash(now.Add(-trashRetention))
+	for _, event := range purged {
+		releaseBlobs(event.Attachments)
+	}
+	if len(purged) > 0 {
+		log.Printf("Purged %d events from the trash\n", len(purged))
+	}
+	return len(purged)
+}
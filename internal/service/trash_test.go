@@ -0,0 +1,144 @@
+package service
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTrash(t *testing.T) {
+	storage.Clear()
+
+	calendar, err := CreateCalendar("1", "Work", "", "", "")
+	if err != nil {
+		t.Fatalf("CreateCalendar() error = %v", err)
+	}
+	standup, err := CreateEvent("1", "2024-01-15", "Standup", "", InCalendar(strconv.FormatUint(calendar.CalendarID, 10)))
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	lunch, err := CreateEvent("1", "2024-01-15", "Lunch", "")
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	standupID, lunchID := strconv.FormatUint(standup.EventID, 10), strconv.FormatUint(lunch.EventID, 10)
+
+	if err = DeleteEvent("1", standupID); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+	if err = DeleteEvent("1", lunchID); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+	events, err := GetEventsForDay("1", "2024-01-15")
+	if err != nil || len(events) != 0 {
+		t.Errorf("Deleted events listed: %+v, error = %v", events, err)
+	}
+
+	if _, err = ShareCalendar("1", "2", models.AccessRead); err != nil {
+		t.Fatalf("ShareCalendar() error = %v", err)
+	}
+	if _, err = GetTrash("1", Actor(2)); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("Read viewer trash error = %v, want %v", err, models.ErrForbidden)
+	}
+	if _, err = RestoreEvent("1", lunchID, Actor(2)); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("Read viewer restore error = %v, want %v", err, models.ErrForbidden)
+	}
+
+	trash, err := GetTrash("1")
+	if err != nil {
+		t.Fatalf("GetTrash() error = %v", err)
+	}
+	if len(trash) != 2 || !trash[0].PurgeAt.Equal(trash[0].DeletedAt.Add(trashRetention)) {
+		t.Fatalf("Trash = %+v", trash)
+	}
+
+	// The calendar of the standup is gone, so it returns to the default one.
+	if err = DeleteCalendar("1", strconv.FormatUint(calendar.CalendarID, 10)); err != nil {
+		t.Fatalf("DeleteCalendar() error = %v", err)
+	}
+	restored, err := RestoreEvent("1", standupID)
+	if err != nil {
+		t.Fatalf("RestoreEvent() error = %v", err)
+	}
+	if restored.CalendarID != models.DefaultCalendarID {
+		t.Errorf("Restored into calendar %d", restored.CalendarID)
+	}
+	if _, err = RestoreEvent("1", standupID); !errors.Is(err, models.ErrNotInTrash) {
+		t.Errorf("RestoreEvent() twice error = %v, want %v", err, models.ErrNotInTrash)
+	}
+	events, err = GetEventsForDay("1", "2024-01-15")
+	if err != nil || len(events) != 1 || events[0].Title != "Standup" {
+		t.Errorf("Events after restore = %+v, error = %v", events, err)
+	}
+
+	if n := PurgeTrash(time.Now()); n != 0 {
+		t.Errorf("PurgeTrash() before retention = %d, want 0", n)
+	}
+	if n := PurgeTrash(time.Now().Add(trashRetention + time.Minute)); n != 1 {
+		t.Errorf("PurgeTrash() after retention = %d, want 1", n)
+	}
+	if _, err = RestoreEvent("1", lunchID); !errors.Is(err, models.ErrNotInTrash) {
+		t.Errorf("RestoreEvent() of purged event error = %v, want %v", err, models.ErrNotInTrash)
+	}
+}
+
+func TestRestoreEventChecks(t *testing.T) {
+	storage.Clear()
+
+	oncall, err := CreateCalendar("1", "On-call", "", "", models.ConflictReject)
+	if err != nil {
+		t.Fatalf("CreateCalendar() error = %v", err)
+	}
+	oncallID := strconv.FormatUint(oncall.CalendarID, 10)
+	page, err := CreateEvent("1", "2024-01-15", "Page", "Pager duty", WithTime("09:00"), InCalendar(oncallID), WithVisibility(models.VisibilityPrivate))
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	pageID := strconv.FormatUint(page.EventID, 10)
+	if err = DeleteEvent("1", pageID); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+
+	if _, err = ShareCalendar("1", "2", models.AccessWrite); err != nil {
+		t.Fatalf("ShareCalendar() error = %v", err)
+	}
+	trash, err := GetTrash("1", Actor(2))
+	if err != nil {
+		t.Fatalf("GetTrash() error = %v", err)
+	}
+	if len(trash) != 1 || trash[0].Event.Title != "Busy" || trash[0].Event.Description != "" {
+		t.Errorf("Private event should be masked in the trash: %+v", trash)
+	}
+
+	incident, err := CreateEvent("1", "2024-01-15", "Incident", "", WithTime("09:30"), InCalendar(oncallID))
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	if _, err = RestoreEvent("1", pageID); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("RestoreEvent() into a rejecting calendar error = %v, want %v", err, models.ErrConflict)
+	}
+	if err = DeleteEvent("1", strconv.FormatUint(incident.EventID, 10)); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+
+	if _, err = DefineField("1", oncallID, "ticket", models.FieldString, "", "true"); err != nil {
+		t.Fatalf("DefineField() error = %v", err)
+	}
+	if _, err = RestoreEvent("1", pageID); !errors.Is(err, models.ErrFieldIsRequired) {
+		t.Errorf("RestoreEvent() without a required field error = %v, want %v", err, models.ErrFieldIsRequired)
+	}
+	if err = DeleteField("1", oncallID, "ticket"); err != nil {
+		t.Fatalf("DeleteField() error = %v", err)
+	}
+
+	restored, err := RestoreEvent("1", pageID, Actor(2))
+	if err != nil {
+		t.Fatalf("RestoreEvent() error = %v", err)
+	}
+	if restored.Title != "Busy" || restored.Description != "" {
+		t.Errorf("Restored private event should be masked: %+v", restored)
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/storage"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	DigestEmail   = "email"
+	DigestWebhook = "webhook"
+	DigestFile    = "file"
+)
+
+type UserOption func(*models.User) error
+
+func WithEmail(value string) UserOption {
+	return func(user *models.User) error {
+		if value == "" {
+			return nil
+		}
+		addr, err := mail.ParseAddress(value)
+		if err != nil {
+			return models.ErrInvalidEmail
+		}
+		user.Email = addr.Address
+		return nil
+	}
+}
+
+func WithTimeZone(value string) UserOption {
+	return func(user *models.User) error {
+		if value == "" {
+			return nil
+		}
+		if _, err := time.LoadLocation(value); err != nil {
+			return models.ErrInvalidTimeZone
+		}
+		user.TimeZone = value
+		return nil
+	}
+}
+
+// WithDigest enables a daily or weekly digest delivered at the local time at
+// through channel. Period "off" disables it.
+func WithDigest(period, at, channel, target string) UserOption {
+	return func(user *models.User) error {
+		switch period {
+		case "":
+			return nil
+		case "off":
+			user.Digest = models.DigestSettings{}
+			return nil
+		case models.PeriodDay, models.PeriodWeek:
+		default:
+			return models.ErrInvalidDigest
+		}
+		if _, err := time.Parse(models.TimeFormat, at); err != nil {
+			return models.ErrInvalidDigest
+		}
+		switch channel {
+		case DigestEmail, DigestFile:
+		case DigestWebhook:
+			u, err := url.Parse(target)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return models.ErrInvalidURL
+			}
+		default:
+			return models.ErrInvalidDigest
+		}
+		user.Digest = models.DigestSettings{Period: period, Time: at, Channel: channel, Target: target}
+		return nil
+	}
+}
+
+// UpdateUser applies the options to the profile of userID, creating it if
+// needed.
+func UpdateUser(userID string, opts ...UserOption) (*models.User, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := storage.GetUser(uID)
+	if errors.Is(err, models.ErrUserNotFound) {
+		user = models.User{UserID: uID}
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, opt := range opts {
+		if err = opt(&user); err != nil {
+			return nil, err
+		}
+	}
+	if user.Digest.Channel == DigestEmail && user.Email == "" {
+		return nil, models.ErrInvalidEmail
+	}
+
+	storage.SetUser(&user)
+	return &user, nil
+}
+
+func GetUser(userID string) (*models.User, error) {
+	uID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	user, err := storage.GetUser(uID)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UserEmail returns the address notifications for userID are mailed to.
+func UserEmail(userID uint64) (string, bool) {
+	user, err := storage.GetUser(userID)
+	if err != nil || user.Email == "" {
+		return "", false
+	}
+	return user.Email, true
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"http-calendar/internal/models"
+	"sort"
+	"time"
+)
+
+func CreateAppointmentType(appointment *models.AppointmentType) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if storage.appointmentTypes == nil {
+		storage.appointmentTypes = make(map[uint64]models.AppointmentType)
+	}
+	if _, exists := storage.appointmentTypes[appointment.AppointmentTypeID]; exists {
+		return models.ErrInvalidAppointmentType
+	}
+	if !calendarExists(appointment.UserID, appointment.CalendarID) {
+		return models.ErrCalendarNotFound
+	}
+	storage.appointmentTypes[appointment.AppointmentTypeID] = *appointment
+	return nil
+}
+
+func DeleteAppointmentType(userID, appointmentTypeID uint64) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	appointment, ok := storage.appointmentTypes[appointmentTypeID]
+	if !ok || appointment.UserID != userID {
+		return models.ErrAppointmentTypeNotFound
+	}
+	delete(storage.appointmentTypes, appointmentTypeID)
+	return nil
+}
+
+// GetAppointmentTypeByToken returns the appointment type published under
+// token.
+func GetAppointmentTypeByToken(token string) (models.AppointmentType, error) {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	for _, appointment := range storage.appointmentTypes {
+		if appointment.Token == token {
+			return appointment, nil
+		}
+	}
+	return models.AppointmentType{}, models.ErrAppointmentTypeNotFound
+}
+
+// GetAppointmentTypes returns the appointment types of userID ordered by
+// title.
+func GetAppointmentTypes(userID uint64) []models.AppointmentType {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	result := make([]models.AppointmentType, 0)
+	for _, appointment := range storage.appointmentTypes {
+		if appointment.UserID == userID {
+			result = append(result, appointment)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Title != result[j].Title {
+			return result[i].Title < result[j].Title
+		}
+		return result[i].AppointmentTypeID < result[j].AppointmentTypeID
+	})
+	return result
+}
+
+// GetAppointments returns the events of the owner of appointment booked
+// through it that overlap [from, to).
+func GetAppointments(appointment models.AppointmentType, from, to time.Time) []models.Event {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	result := make([]models.Event, 0)
+	for _, event := range storage.m[appointment.UserID] {
+		if event.AppointmentTypeID == appointment.AppointmentTypeID && event.Start().Before(to) && event.End().After(from) {
+			result = append(result, event)
+		}
+	}
+	return result
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"http-calendar/internal/models"
+	"http-calendar/internal/pubsub"
+)
+
+// indexAttachments moves the references to blobs from the attachments of
+// previous to the ones of event. Either may be nil. The caller must hold the
+// lock.
+func indexAttachments(previous, event *models.Event) {
+	if previous != nil {
+		for _, attachment := range previous.Attachments {
+			if storage.blobs[attachment.Hash]--; storage.blobs[attachment.Hash] <= 0 {
+				delete(storage.blobs, attachment.Hash)
+			}
+		}
+	}
+	if event == nil || len(event.Attachments) == 0 {
+		return
+	}
+	if storage.blobs == nil {
+		storage.blobs = make(map[string]int)
+	}
+	for _, attachment := range event.Attachments {
+		storage.blobs[attachment.Hash]++
+	}
+}
+
+// UpdateAttachments replaces the attachments of an event with the result
+// of update, which runs under the lock.
+func UpdateAttachments(userID, eventID uint64, update func([]models.Attachment) ([]models.Attachment, error)) (models.Event, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	previous, ok := storage.m[userID][eventID]
+	if !ok {
+		return models.Event{}, models.ErrEventNotFound
+	}
+	attachments, err := update(previous.Attachments)
+	if err != nil {
+		return models.Event{}, err
+	}
+	event := previous
+	event.Attachments = attachments
+	storage.m[userID][eventID] = event
+	indexAttachments(&previous, &event)
+	recordChange(userID, eventID, false)
+	pubsub.Publish(models.ChangeUpdated, event)
+	return event, nil
+}
+
+// BlobInUse reports whether an event is attached to the blob with hash.
+func BlobInUse(hash string) bool {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+	return storage.blobs[hash] > 0
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"http-calendar/internal/models"
+	"slices"
+)
+
+// UpdateAvailability changes the availability of userID through update,
+// which gets a copy of the current one. Nothing is stored when update
+// fails.
+func UpdateAvailability(userID uint64, update func(*models.Availability) error) (models.Availability, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	availability, ok := storage.availability[userID]
+	if !ok {
+		availability = models.Availability{UserID: userID}
+	}
+	availability.WorkingHours = slices.Clone(availability.WorkingHours)
+	availability.OutOfOffice = slices.Clone(availability.OutOfOffice)
+	if err := update(&availability); err != nil {
+		return models.Availability{}, err
+	}
+
+	if storage.availability == nil {
+		storage.availability = make(map[uint64]models.Availability)
+	}
+	storage.availability[userID] = availability
+	return availability, nil
+}
+
+// GetAvailability returns the availability of userID, which is empty when
+// the user did not set one.
+func GetAvailability(userID uint64) models.Availability {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	availability, ok := storage.availability[userID]
+	if !ok {
+		return models.Availability{UserID: userID}
+	}
+	return availability
+}
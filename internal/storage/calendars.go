@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"http-calendar/internal/models"
+	"sort"
+)
+
+func CreateCalendar(calendar *models.Calendar) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if storage.calendars == nil {
+		storage.calendars = make(map[uint64]map[uint64]models.Calendar)
+	}
+	if storage.calendars[calendar.UserID] == nil {
+		storage.calendars[calendar.UserID] = make(map[uint64]models.Calendar)
+	}
+	if _, exists := storage.calendars[calendar.UserID][calendar.CalendarID]; exists {
+		return models.ErrExistingCalendar
+	}
+	storage.calendars[calendar.UserID][calendar.CalendarID] = *calendar
+	return nil
+}
+
+// UpdateCalendar changes a calendar of userID through update, which runs
+// under the lock on the stored calendar.
+func UpdateCalendar(userID, calendarID uint64, update func(*models.Calendar) error) (models.Calendar, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	calendar, ok := storage.calendars[userID][calendarID]
+	if !ok {
+		return models.Calendar{}, models.ErrCalendarNotFound
+	}
+	if err := update(&calendar); err != nil {
+		return models.Calendar{}, err
+	}
+	storage.calendars[userID][calendarID] = calendar
+	return calendar, nil
+}
+
+// DeleteCalendar removes an empty calendar. Events have to be deleted or
+// moved to another calendar first.
+func DeleteCalendar(userID, calendarID uint64) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if _, ok := storage.calendars[userID][calendarID]; !ok {
+		return models.ErrCalendarNotFound
+	}
+	for _, event := range storage.m[userID] {
+		if event.CalendarID == calendarID {
+			return models.ErrCalendarNotEmpty
+		}
+	}
+	for _, task := range storage.tasks[userID] {
+		if task.CalendarID == calendarID {
+			return models.ErrCalendarNotEmpty
+		}
+	}
+	delete(storage.calendars[userID], calendarID)
+	delete(storage.fields[userID], calendarID)
+	return nil
+}
+
+func GetCalendar(userID, calendarID uint64) (models.Calendar, error) {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	calendar, ok := storage.calendars[userID][calendarID]
+	if !ok {
+		return models.Calendar{}, models.ErrCalendarNotFound
+	}
+	return calendar, nil
+}
+
+// GetCalendars returns the named calendars of userID ordered by name.
+func GetCalendars(userID uint64) []models.Calendar {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	result := make([]models.Calendar, 0, len(storage.calendars[userID]))
+	for _, calendar := range storage.calendars[userID] {
+		result = append(result, calendar)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].CalendarID < result[j].CalendarID
+	})
+	return result
+}
+
+// calendarExists reports whether events of userID may be put into
+// calendarID. The caller must hold the lock.
+func calendarExists(userID, calendarID uint64) bool {
+	if calendarID == models.DefaultCalendarID {
+		return true
+	}
+	_, ok := storage.calendars[userID][calendarID]
+	return ok
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"http-calendar/internal/models"
+	"slices"
+)
+
+// UpdateFields changes the schema of custom fields of a calendar of userID
+// through update, which runs under the lock and must not modify the schema
+// it is given.
+func UpdateFields(userID, calendarID uint64, update func([]models.FieldDefinition) ([]models.FieldDefinition, error)) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if !calendarExists(userID, calendarID) {
+		return models.ErrCalendarNotFound
+	}
+	schema, err := update(storage.fields[userID][calendarID])
+	if err != nil {
+		return err
+	}
+	if storage.fields == nil {
+		storage.fields = make(map[uint64]map[uint64][]models.FieldDefinition)
+	}
+	if storage.fields[userID] == nil {
+		storage.fields[userID] = make(map[uint64][]models.FieldDefinition)
+	}
+	storage.fields[userID][calendarID] = schema
+	return nil
+}
+
+func DeleteField(userID, calendarID uint64, name string) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	schema := storage.fields[userID][calendarID]
+	i := slices.IndexFunc(schema, func(f models.FieldDefinition) bool { return f.Name == name })
+	if i < 0 {
+		return models.ErrFieldNotFound
+	}
+	storage.fields[userID][calendarID] = slices.Delete(slices.Clone(schema), i, i+1)
+	return nil
+}
+
+// GetFields returns the custom fields of a calendar of userID in the order
+// they were defined.
+func GetFields(userID, calendarID uint64) []models.FieldDefinition {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	return append(make([]models.FieldDefinition, 0), storage.fields[userID][calendarID]...)
+}
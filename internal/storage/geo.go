@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"http-calendar/internal/models"
+	"math"
+	"time"
+)
+
+// geoCellDegrees is the size of the grid cells of the spatial index.
+const geoCellDegrees = 1.0
+
+// Longitude 180 is stored in the cell of -180, so that cells past the
+// antimeridian wrap around.
+var (
+	antimeridianCell = int(180 / geoCellDegrees)
+	longitudeCells   = int(360 / geoCellDegrees)
+)
+
+// geoCell is a cell of a grid over latitude and longitude.
+type geoCell struct {
+	lat, lon int
+}
+
+func cellOf(p models.Point) geoCell {
+	return geoCell{lat: cellIndex(p.Latitude), lon: wrapCell(cellIndex(p.Longitude))}
+}
+
+func cellIndex(degrees float64) int {
+	return int(math.Floor(degrees / geoCellDegrees))
+}
+
+func wrapCell(lon int) int {
+	if lon >= antimeridianCell {
+		return lon - longitudeCells
+	}
+	return lon
+}
+
+// indexGeo moves previous out of its grid cell and event into its own.
+// Either may be nil or have no coordinates. The caller must hold the lock.
+func indexGeo(previous, event *models.Event) {
+	if previous != nil {
+		if p, ok := previous.Location.Point(); ok {
+			cells := storage.geo[previous.UserID]
+			cell := cellOf(p)
+			delete(cells[cell], previous.EventID)
+			if len(cells[cell]) == 0 {
+				delete(cells, cell)
+			}
+		}
+	}
+	if event == nil {
+		return
+	}
+	p, ok := event.Location.Point()
+	if !ok {
+		return
+	}
+	if storage.geo == nil {
+		storage.geo = make(map[uint64]map[geoCell]map[uint64]struct{})
+	}
+	if storage.geo[event.UserID] == nil {
+		storage.geo[event.UserID] = make(map[geoCell]map[uint64]struct{})
+	}
+	cell := cellOf(p)
+	if storage.geo[event.UserID][cell] == nil {
+		storage.geo[event.UserID][cell] = make(map[uint64]struct{})
+	}
+	storage.geo[event.UserID][cell][event.EventID] = struct{}{}
+}
+
+// GetEventsInBox returns the events of userID with coordinates inside box
+// that overlap [from, to).
+func GetEventsInBox(userID uint64, box models.BoundingBox, from, to time.Time) []models.Event {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	result := make([]models.Event, 0)
+	cells := storage.geo[userID]
+	if len(cells) == 0 {
+		return result
+	}
+	collect := func(ids map[uint64]struct{}) {
+		for id := range ids {
+			event := storage.m[userID][id]
+			p, _ := event.Location.Point()
+			if box.Contains(p) && event.Start().Before(to) && event.End().After(from) {
+				result = append(result, event)
+			}
+		}
+	}
+
+	// Visit the cells of the box, or the occupied cells of the user when
+	// there are fewer of them.
+	south, north := cellIndex(box.MinLatitude), cellIndex(box.MaxLatitude)
+	west, east := cellIndex(box.MinLongitude), cellIndex(box.MaxLongitude)
+	if box.MinLongitude > box.MaxLongitude {
+		east += longitudeCells
+	}
+	if (north-south+1)*(east-west+1) > len(cells) {
+		for _, ids := range cells {
+			collect(ids)
+		}
+		return result
+	}
+	for lat := south; lat <= north; lat++ {
+		for lon := west; lon <= east; lon++ {
+			collect(cells[geoCell{lat: lat, lon: wrapCell(lon)}])
+		}
+	}
+	return result
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"http-calendar/internal/models"
+	"time"
+)
+
+type eventKey struct {
+	userID  uint64
+	eventID uint64
+}
+
+// indexInvites points the users invited to event at it, replacing what was
+// indexed for previous. Either may be nil. The caller must hold the lock.
+func indexInvites(previous, event *models.Event) {
+	if previous != nil {
+		key := eventKey{previous.UserID, previous.EventID}
+		for _, attendee := range previous.Attendees {
+			if attendee.UserID != 0 {
+				delete(storage.invites[attendee.UserID], key)
+			}
+		}
+	}
+	if event == nil {
+		return
+	}
+	key := eventKey{event.UserID, event.EventID}
+	for _, attendee := range event.Attendees {
+		if attendee.UserID == 0 {
+			continue
+		}
+		if storage.invites == nil {
+			storage.invites = make(map[uint64]map[eventKey]struct{})
+		}
+		if storage.invites[attendee.UserID] == nil {
+			storage.invites[attendee.UserID] = make(map[eventKey]struct{})
+		}
+		storage.invites[attendee.UserID][key] = struct{}{}
+	}
+}
+
+// GetInvitedEvents returns the events of other users that userID is invited
+// to and that take place on a day in [from, to).
+func GetInvitedEvents(userID uint64, from, to time.Time) []models.Event {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	result := make([]models.Event, 0)
+	for key := range storage.invites[userID] {
+		event, ok := storage.m[key.userID][key.eventID]
+		if !ok {
+			continue
+		}
+		day := time.Date(event.Date.Year(), event.Date.Month(), event.Date.Day(), 0, 0, 0, 0, from.Location())
+		if !day.Before(from) && day.Before(to) {
+			result = append(result, event)
+		}
+	}
+	return result
+}
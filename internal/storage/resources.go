@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"http-calendar/internal/models"
+	"http-calendar/internal/pubsub"
+	"slices"
+	"sort"
+	"time"
+)
+
+func CreateResource(resource *models.Resource) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if storage.resources == nil {
+		storage.resources = make(map[uint64]models.Resource)
+	}
+	if _, exists := storage.resources[resource.ResourceID]; exists {
+		return models.ErrInvalidResource
+	}
+	storage.resources[resource.ResourceID] = *resource
+	return nil
+}
+
+// DeleteResource removes a resource that has no bookings left, apart from
+// rejected ones.
+func DeleteResource(resourceID uint64) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if _, ok := storage.resources[resourceID]; !ok {
+		return models.ErrResourceNotFound
+	}
+	if len(storage.bookings[resourceID]) > 0 {
+		return models.ErrResourceInUse
+	}
+	delete(storage.resources, resourceID)
+	return nil
+}
+
+// UpdateBooking changes the booking of resourceID by an event of userID
+// through update. Bookings that hold the resource afterwards must find it
+// free.
+func UpdateBooking(userID, eventID, resourceID uint64, update func(models.Booking) (models.Booking, error)) (models.Event, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	previous, ok := storage.m[userID][eventID]
+	if !ok {
+		return models.Event{}, models.ErrEventNotFound
+	}
+	i := slices.IndexFunc(previous.Bookings, func(b models.Booking) bool { return b.ResourceID == resourceID })
+	if i < 0 {
+		return models.Event{}, models.ErrResourceNotFound
+	}
+	booking, err := update(previous.Bookings[i])
+	if err != nil {
+		return models.Event{}, err
+	}
+	event := previous
+	event.Bookings = slices.Clone(previous.Bookings)
+	event.Bookings[i] = booking
+	if err = checkBookings(&event); err != nil {
+		return models.Event{}, err
+	}
+	storage.m[userID][eventID] = event
+	indexBookings(&previous, &event)
+	recordChange(userID, eventID, false)
+	pubsub.Publish(models.ChangeUpdated, event)
+	return event, nil
+}
+
+func GetResource(resourceID uint64) (models.Resource, error) {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	resource, ok := storage.resources[resourceID]
+	if !ok {
+		return models.Resource{}, models.ErrResourceNotFound
+	}
+	return resource, nil
+}
+
+// GetResources returns all resources ordered by name.
+func GetResources() []models.Resource {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	result := make([]models.Resource, 0, len(storage.resources))
+	for _, resource := range storage.resources {
+		result = append(result, resource)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].ResourceID < result[j].ResourceID
+	})
+	return result
+}
+
+// GetBookedEvents returns the events that booked resourceID and were not
+// rejected, overlapping [from, to).
+func GetBookedEvents(resourceID uint64, from, to time.Time) []models.Event {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	result := make([]models.Event, 0)
+	for key := range storage.bookings[resourceID] {
+		event, ok := storage.m[key.userID][key.eventID]
+		if ok && holds(event, resourceID) && event.Start().Before(to) && event.End().After(from) {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+// indexBookings points the resources booked by event at it, replacing what
+// was indexed for previous. Either may be nil. The caller must hold the lock.
+func indexBookings(previous, event *models.Event) {
+	if previous != nil {
+		key := eventKey{previous.UserID, previous.EventID}
+		for _, booking := range previous.Bookings {
+			delete(storage.bookings[booking.ResourceID], key)
+		}
+	}
+	if event == nil {
+		return
+	}
+	key := eventKey{event.UserID, event.EventID}
+	for _, booking := range event.Bookings {
+		if booking.Status == models.BookingRejected {
+			continue
+		}
+		if storage.bookings == nil {
+			storage.bookings = make(map[uint64]map[eventKey]struct{})
+		}
+		if storage.bookings[booking.ResourceID] == nil {
+			storage.bookings[booking.ResourceID] = make(map[eventKey]struct{})
+		}
+		storage.bookings[booking.ResourceID][key] = struct{}{}
+	}
+}
+
+// checkBookings verifies that every resource booked by event exists and has
+// a unit left for its time. The caller must hold the lock, which makes the
+// check and the following write atomic.
+func checkBookings(event *models.Event) error {
+	if event.Status == models.EventCancelled {
+		return nil
+	}
+	start, end := event.Start(), event.End()
+	self := eventKey{event.UserID, event.EventID}
+	for _, booking := range event.Bookings {
+		if booking.Status == models.BookingRejected {
+			continue
+		}
+		resource, ok := storage.resources[booking.ResourceID]
+		if !ok {
+			return models.ErrResourceNotFound
+		}
+		var overlapping []models.Event
+		for key := range storage.bookings[booking.ResourceID] {
+			other, ok := storage.m[key.userID][key.eventID]
+			if key == self || !ok || !holds(other, booking.ResourceID) {
+				continue
+			}
+			if other.Start().Before(end) && other.End().After(start) {
+				overlapping = append(overlapping, other)
+			}
+		}
+		if peakOverlap(overlapping, start, end) >= max(resource.Quantity, 1) {
+			return &models.ResourceBusyError{ResourceID: booking.ResourceID}
+		}
+	}
+	return nil
+}
+
+// peakOverlap returns the largest number of events that take place at the
+// same moment within [start, end). Events that end when another one starts
+// do not overlap.
+func peakOverlap(events []models.Event, start, end time.Time) int {
+	type edge struct {
+		at    time.Time
+		delta int
+	}
+	edges := make([]edge, 0, 2*len(events))
+	for _, event := range events {
+		from, to := event.Start(), event.End()
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		edges = append(edges, edge{from, 1}, edge{to, -1})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if !edges[i].at.Equal(edges[j].at) {
+			return edges[i].at.Before(edges[j].at)
+		}
+		return edges[i].delta < edges[j].delta
+	})
+
+	peak, current := 0, 0
+	for _, e := range edges {
+		current += e.delta
+		peak = max(peak, current)
+	}
+	return peak
+}
+
+// holds reports whether event has a booking of resourceID that was not
+// rejected, and was not cancelled.
+func holds(event models.Event, resourceID uint64) bool {
+	if event.Status == models.EventCancelled {
+		return false
+	}
+	for _, booking := range event.Bookings {
+		if booking.ResourceID == resourceID {
+			return booking.Status != models.BookingRejected
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"http-calendar/internal/models"
+	"http-calendar/internal/search"
+)
+
+// Terms of the title weigh more than terms of the description.
+const (
+	titleWeight       = 2
+	descriptionWeight = 1
+)
+
+// indexText replaces the terms indexed for previous with the ones of event.
+// Either may be nil. The caller must hold the lock.
+func indexText(previous, event *models.Event) {
+	if previous != nil && event == nil {
+		if index := storage.text[previous.UserID]; index != nil {
+			index.Remove(previous.EventID)
+		}
+		return
+	}
+	if event == nil {
+		return
+	}
+	if storage.text == nil {
+		storage.text = make(map[uint64]*search.Index)
+	}
+	index := storage.text[event.UserID]
+	if index == nil {
+		index = search.NewIndex()
+		storage.text[event.UserID] = index
+	}
+	index.Add(event.EventID,
+		search.Field{Text: event.Title, Weight: titleWeight},
+		search.Field{Text: event.Description, Weight: descriptionWeight},
+	)
+}
+
+// SearchEvents returns the events of userID whose title or description
+// match query, the most relevant first.
+func SearchEvents(userID uint64, query string) []models.SearchResult {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	result := make([]models.SearchResult, 0)
+	index := storage.text[userID]
+	if index == nil {
+		return result
+	}
+	for _, hit := range index.Search(query) {
+		if event, ok := storage.m[userID][hit.ID]; ok {
+			result = append(result, models.SearchResult{Event: event, Score: hit.Score})
+		}
+	}
+	return result
+}
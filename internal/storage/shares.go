@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"http-calendar/internal/models"
+	"sort"
+)
+
+func SetShare(share *models.Share) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if storage.shares == nil {
+		storage.shares = make(map[uint64]map[uint64]models.Share)
+	}
+	if storage.shares[share.OwnerID] == nil {
+		storage.shares[share.OwnerID] = make(map[uint64]models.Share)
+	}
+	storage.shares[share.OwnerID][share.GranteeID] = *share
+}
+
+func DeleteShare(ownerID, granteeID uint64) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if _, ok := storage.shares[ownerID][granteeID]; !ok {
+		return models.ErrShareNotFound
+	}
+	delete(storage.shares[ownerID], granteeID)
+	return nil
+}
+
+func GetShare(ownerID, granteeID uint64) (models.Share, error) {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	share, ok := storage.shares[ownerID][granteeID]
+	if !ok {
+		return models.Share{}, models.ErrShareNotFound
+	}
+	return share, nil
+}
+
+// GetShares returns the shares granted by userID and the shares granted to
+// userID by others.
+func GetShares(userID uint64) models.Shares {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	result := models.Shares{Granted: make([]models.Share, 0), Received: make([]models.Share, 0)}
+	for _, share := range storage.shares[userID] {
+		result.Granted = append(result.Granted, share)
+	}
+	for ownerID, shares := range storage.shares {
+		if share, ok := shares[userID]; ok && ownerID != userID {
+			result.Received = append(result.Received, share)
+		}
+	}
+	sort.Slice(result.Granted, func(i, j int) bool { return result.Granted[i].GranteeID < result.Granted[j].GranteeID })
+	sort.Slice(result.Received, func(i, j int) bool { return result.Received[i].OwnerID < result.Received[j].OwnerID })
+	return result
+}
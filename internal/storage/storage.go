@@ -2,6 +2,9 @@ package storage
 
 import (
 	"http-calendar/internal/models"
+	"http-calendar/internal/pubsub"
+	"http-calendar/internal/search"
+	"sort"
 	"sync"
 	"time"
 )
@@ -9,9 +12,47 @@ import (
 var storage struct {
 	mu sync.RWMutex
 	m  map[uint64]map[uint64]models.Event
+
+	seq        map[uint64]uint64
+	changed    map[uint64]map[uint64]uint64
+	tombstones map[uint64]map[uint64]tombstone
+	// deletions lists the tombstones of each user in the order they were
+	// made, and horizon the latest sequence whose tombstone was pruned.
+	deletions map[uint64][]deletion
+	horizon   map[uint64]uint64
+	epoch     int64
+
+	users     map[uint64]models.User
+	shares    map[uint64]map[uint64]models.Share
+	calendars map[uint64]map[uint64]models.Calendar
+	invites   map[uint64]map[eventKey]struct{}
+
+	availability map[uint64]models.Availability
+	resources    map[uint64]models.Resource
+	bookings     map[uint64]map[eventKey]struct{}
+
+	appointmentTypes map[uint64]models.AppointmentType
+	tasks            map[uint64]map[uint64]models.Task
+
+	text map[uint64]*search.Index
+	tags map[uint64]map[string]int
+	geo  map[uint64]map[geoCell]map[uint64]struct{}
+
+	blobs  map[string]int
+	fields map[uint64]map[uint64][]models.FieldDefinition
+	trash  map[uint64]map[uint64]models.TrashedEvent
 }
 
-func CreateEvent(event *models.Event) error {
+// Check decides under the storage lock whether an event may be written,
+// given the other events of its owner that overlap it.
+type Check func(overlapping []models.Event) error
+
+type tombstone struct {
+	seq       uint64
+	deletedAt time.Time
+}
+
+func CreateEvent(event *models.Event, checks ...Check) error {
 	storage.mu.Lock()
 	defer storage.mu.Unlock()
 
@@ -26,43 +67,128 @@ func CreateEvent(event *models.Event) error {
 	if _, exists := storage.m[event.UserID][event.EventID]; exists {
 		return models.ErrExistingEvent
 	}
+	if !calendarExists(event.UserID, event.CalendarID) {
+		return models.ErrCalendarNotFound
+	}
+	if err := checkBookings(event); err != nil {
+		return err
+	}
+	if err := runChecks(event, checks); err != nil {
+		return err
+	}
 
 	storage.m[event.UserID][event.EventID] = *event
+	indexEvent(nil, event)
+	indexAttachments(nil, event)
+	recordChange(event.UserID, event.EventID, false)
+	pubsub.Publish(models.ChangeCreated, *event)
 	return nil
 }
 
-func UpdateEvent(event *models.Event) error {
+func UpdateEvent(event *models.Event, checks ...Check) error {
 	storage.mu.Lock()
 	defer storage.mu.Unlock()
+	return updateEvent(event, checks)
+}
+
+// UpdateEventAt is UpdateEvent for an event that was read at version, see
+// GetEventAt. It fails with ErrEventChanged when the event was written since,
+// so that the caller can start over from the stored event.
+func UpdateEventAt(event *models.Event, version uint64, checks ...Check) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if _, ok := storage.m[event.UserID][event.EventID]; ok && storage.changed[event.UserID][event.EventID] != version {
+		return models.ErrEventChanged
+	}
+	return updateEvent(event, checks)
+}
 
+func updateEvent(event *models.Event, checks []Check) error {
 	if storage.m == nil {
 		return models.ErrUserNotFound
-	} else {
-		_, ok := storage.m[event.UserID][event.EventID]
-		if !ok {
-			return models.ErrEventNotFound
-		}
+	}
+	previous, ok := storage.m[event.UserID][event.EventID]
+	if !ok {
+		return models.ErrEventNotFound
+	}
+	if !calendarExists(event.UserID, event.CalendarID) {
+		return models.ErrCalendarNotFound
+	}
+	if err := checkBookings(event); err != nil {
+		return err
+	}
+	if err := runChecks(event, checks); err != nil {
+		return err
 	}
 	storage.m[event.UserID][event.EventID] = *event
+	indexEvent(&previous, event)
+	indexAttachments(&previous, event)
+	recordChange(event.UserID, event.EventID, false)
+	pubsub.Publish(models.ChangeUpdated, *event)
 	return nil
 }
 
+// indexEvent moves the indexes of live events from previous to event.
+// Either may be nil. The caller must hold the lock.
+func indexEvent(previous, event *models.Event) {
+	indexInvites(previous, event)
+	indexBookings(previous, event)
+	indexText(previous, event)
+	indexTags(previous, event)
+	indexGeo(previous, event)
+}
+
+// DeleteEvent removes an event permanently, see TrashEvent.
 func DeleteEvent(userID, eventID uint64) error {
 	storage.mu.Lock()
 	defer storage.mu.Unlock()
 
 	if storage.m == nil {
 		return models.ErrUserNotFound
-	} else {
-		_, ok := storage.m[userID][eventID]
-		if !ok {
-			return models.ErrEventNotFound
-		}
+	}
+	event, ok := storage.m[userID][eventID]
+	if !ok {
+		return models.ErrEventNotFound
 	}
 	delete(storage.m[userID], eventID)
+	indexEvent(&event, nil)
+	indexAttachments(&event, nil)
+	recordChange(userID, eventID, true)
+	pubsub.Publish(models.ChangeDeleted, event)
 	return nil
 }
 
+func GetEvent(userID, eventID uint64) (models.Event, error) {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	if storage.m == nil {
+		return models.Event{}, models.ErrUserNotFound
+	}
+	event, ok := storage.m[userID][eventID]
+	if !ok {
+		return models.Event{}, models.ErrEventNotFound
+	}
+	return event, nil
+}
+
+// GetEventAt returns an event of userID with the version it was last written
+// at, for UpdateEventAt.
+func GetEventAt(userID, eventID uint64) (models.Event, uint64, error) {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	if storage.m == nil {
+		return models.Event{}, 0, models.ErrUserNotFound
+	}
+	event, ok := storage.m[userID][eventID]
+	if !ok {
+		return models.Event{}, 0, models.ErrEventNotFound
+	}
+	return event, storage.changed[userID][eventID], nil
+}
+
 func GetEventsForDay(userID uint64, date time.Time) ([]models.Event, error) {
 	storage.mu.Lock()
 	defer storage.mu.Unlock()
@@ -77,7 +203,9 @@ func GetEventsForDay(userID uint64, date time.Time) ([]models.Event, error) {
 
 	result := make([]models.Event, 0, len(values))
 	for _, value := range values {
-		if date.Equal(value.Date) {
+		// Events are listed under their own calendar date, whatever zone
+		// they are read in.
+		if sameDay(date, value.Date) {
 			result = append(result, value)
 		}
 	}
@@ -101,7 +229,7 @@ func GetEventsForWeek(userID uint64, startDate time.Time) ([]models.Event, error
 
 	result := make([]models.Event, 0, len(values))
 	for _, value := range values {
-		eventDate := time.Date(value.Date.Year(), value.Date.Month(), value.Date.Day(), 0, 0, 0, 0, value.Date.Location())
+		eventDate := time.Date(value.Date.Year(), value.Date.Month(), value.Date.Day(), 0, 0, 0, 0, startDate.Location())
 		if (eventDate.Equal(startOfWeek) || eventDate.After(startOfWeek)) && eventDate.Before(endOfWeek) {
 			result = append(result, value)
 		}
@@ -126,7 +254,7 @@ func GetEventsForMonth(userID uint64, startDate time.Time) ([]models.Event, erro
 
 	result := make([]models.Event, 0, len(values))
 	for _, value := range values {
-		eventDate := time.Date(value.Date.Year(), value.Date.Month(), value.Date.Day(), 0, 0, 0, 0, value.Date.Location())
+		eventDate := time.Date(value.Date.Year(), value.Date.Month(), value.Date.Day(), 0, 0, 0, 0, startDate.Location())
 		if (eventDate.Equal(startOfMonth) || eventDate.After(startOfMonth)) && eventDate.Before(endOfMonth) {
 			result = append(result, value)
 		}
@@ -134,6 +262,84 @@ func GetEventsForMonth(userID uint64, startDate time.Time) ([]models.Event, erro
 	return result, nil
 }
 
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month() && a.Day() == b.Day()
+}
+
+func runChecks(event *models.Event, checks []Check) error {
+	if len(checks) == 0 {
+		return nil
+	}
+	overlapping := overlappingEvents(event)
+	for _, check := range checks {
+		if err := check(overlapping); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// overlappingEvents returns the busy events of the owner of event and the
+// invitations the owner did not decline that overlap it, ordered by start.
+// Events that are not busy themselves overlap nothing.
+// The caller must hold the lock.
+func overlappingEvents(event *models.Event) []models.Event {
+	if !event.Busy() {
+		return nil
+	}
+	start, end := event.Start(), event.End()
+	overlaps := func(other models.Event) bool {
+		return other.Busy() && other.Start().Before(end) && other.End().After(start)
+	}
+
+	var result []models.Event
+	for _, other := range storage.m[event.UserID] {
+		if other.EventID != event.EventID && overlaps(other) {
+			result = append(result, other)
+		}
+	}
+	for key := range storage.invites[event.UserID] {
+		other, ok := storage.m[key.userID][key.eventID]
+		if ok && !other.Declined(event.UserID) && overlaps(other) {
+			result = append(result, other)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Start().Equal(result[j].Start()) {
+			return result[i].Start().Before(result[j].Start())
+		}
+		return result[i].EventID < result[j].EventID
+	})
+	return result
+}
+
+// GetEventsBetween returns the events of userID that overlap [from, to).
+func GetEventsBetween(userID uint64, from, to time.Time) []models.Event {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	result := make([]models.Event, 0)
+	for _, value := range storage.m[userID] {
+		if value.Start().Before(to) && value.End().After(from) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func GetAllEvents() []models.Event {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	var result []models.Event
+	for _, values := range storage.m {
+		for _, value := range values {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
 func GetNewEventID() uint64 {
 	return uint64(time.Now().UnixNano())
 }
@@ -142,4 +348,25 @@ func Clear() {
 	storage.mu.Lock()
 	defer storage.mu.Unlock()
 	storage.m = make(map[uint64]map[uint64]models.Event)
+	storage.seq = nil
+	storage.changed = nil
+	storage.tombstones = nil
+	storage.deletions = nil
+	storage.horizon = nil
+	storage.epoch = time.Now().UnixNano()
+	storage.users = nil
+	storage.shares = nil
+	storage.calendars = nil
+	storage.invites = nil
+	storage.availability = nil
+	storage.resources = nil
+	storage.bookings = nil
+	storage.appointmentTypes = nil
+	storage.tasks = nil
+	storage.text = nil
+	storage.tags = nil
+	storage.geo = nil
+	storage.blobs = nil
+	storage.fields = nil
+	storage.trash = nil
 }
@@ -170,3 +170,131 @@ func TestGetEventsForMonth(t *testing.T) {
 		t.Errorf("Expected 2 events, got %d", len(events))
 	}
 }
+
+func TestGetChangesSince(t *testing.T) {
+	Clear()
+
+	for i := uint64(1); i <= 3; i++ {
+		if err := CreateEvent(&models.Event{EventID: i, UserID: 1, Title: "Event"}); err != nil {
+			t.Fatalf("CreateEvent() error = %v", err)
+		}
+	}
+	events, deleted, seq := GetChangesSince(1, 0)
+	if len(events) != 3 || len(deleted) != 0 || seq != 3 {
+		t.Fatalf("Full sync got %d events, %d deleted, seq %d", len(events), len(deleted), seq)
+	}
+
+	if err := UpdateEvent(&models.Event{EventID: 2, UserID: 1, Title: "Updated"}); err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if err := DeleteEvent(1, 3); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+
+	events, deleted, seq = GetChangesSince(1, 3)
+	if len(events) != 1 || events[0].EventID != 2 {
+		t.Errorf("Expected updated event 2, got %+v", events)
+	}
+	if len(deleted) != 1 || deleted[0].EventID != 3 {
+		t.Errorf("Expected tombstone for event 3, got %+v", deleted)
+	}
+	if seq != 5 {
+		t.Errorf("Expected seq 5, got %d", seq)
+	}
+
+	events, deleted, _ = GetChangesSince(1, seq)
+	if len(events) != 0 || len(deleted) != 0 {
+		t.Errorf("Expected no changes, got %d events, %d deleted", len(events), len(deleted))
+	}
+}
+
+func TestPruneTombstones(t *testing.T) {
+	Clear()
+	defer func(limit int) { maxTombstones = limit }(maxTombstones)
+	maxTombstones = 2
+
+	for i := uint64(1); i <= 3; i++ {
+		if err := CreateEvent(&models.Event{EventID: i, UserID: 1, Title: "Event"}); err != nil {
+			t.Fatalf("CreateEvent() error = %v", err)
+		}
+	}
+	for i := uint64(1); i <= 3; i++ {
+		if err := DeleteEvent(1, i); err != nil {
+			t.Fatalf("DeleteEvent() error = %v", err)
+		}
+	}
+
+	_, deleted, _ := GetChangesSince(1, 3)
+	if len(deleted) != 2 {
+		t.Errorf("Expected 2 tombstones, got %+v", deleted)
+	}
+	for _, d := range deleted {
+		if d.EventID == 1 {
+			t.Errorf("Expected tombstone of event 1 to be pruned")
+		}
+	}
+	// Event 1 was deleted at sequence 4.
+	if horizon := SyncHorizon(1); horizon != 4 {
+		t.Errorf("SyncHorizon() = %d, want 4", horizon)
+	}
+	if horizon := SyncHorizon(2); horizon != 0 {
+		t.Errorf("SyncHorizon() of another user = %d, want 0", horizon)
+	}
+}
+
+func TestShares(t *testing.T) {
+	Clear()
+
+	SetShare(&models.Share{OwnerID: 1, GranteeID: 2, Level: models.AccessRead})
+	SetShare(&models.Share{OwnerID: 3, GranteeID: 2, Level: models.AccessWrite})
+	SetShare(&models.Share{OwnerID: 1, GranteeID: 2, Level: models.AccessWrite})
+
+	share, err := GetShare(1, 2)
+	if err != nil || share.Level != models.AccessWrite {
+		t.Errorf("GetShare() = %+v, %v", share, err)
+	}
+	if _, err = GetShare(2, 1); err != models.ErrShareNotFound {
+		t.Errorf("Shares should not be symmetric, error = %v", err)
+	}
+
+	shares := GetShares(2)
+	if len(shares.Granted) != 0 || len(shares.Received) != 2 || shares.Received[0].OwnerID != 1 {
+		t.Errorf("Unexpected shares %+v", shares)
+	}
+
+	if err = DeleteShare(1, 2); err != nil {
+		t.Fatalf("DeleteShare() error = %v", err)
+	}
+	if err = DeleteShare(1, 2); err != models.ErrShareNotFound {
+		t.Errorf("DeleteShare() error = %v, want %v", err, models.ErrShareNotFound)
+	}
+}
+
+func TestCreateEvent_Check(t *testing.T) {
+	Clear()
+
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	if err := CreateEvent(&models.Event{EventID: 1, UserID: 1, Date: date, Time: "09:00", Duration: 60}); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+
+	var seen []models.Event
+	reject := func(overlapping []models.Event) error {
+		seen = overlapping
+		if len(overlapping) > 0 {
+			return models.ErrConflict
+		}
+		return nil
+	}
+
+	err := CreateEvent(&models.Event{EventID: 2, UserID: 1, Date: date, Time: "09:30"}, reject)
+	if err != models.ErrConflict || len(seen) != 1 || seen[0].EventID != 1 {
+		t.Errorf("CreateEvent() error = %v, overlapping %+v", err, seen)
+	}
+	if _, err = GetEvent(1, 2); err != models.ErrEventNotFound {
+		t.Errorf("Rejected event was stored")
+	}
+	if err = CreateEvent(&models.Event{EventID: 3, UserID: 1, Date: date, Time: "10:00"}, reject); err != nil {
+		t.Errorf("CreateEvent() of adjacent event error = %v", err)
+	}
+}
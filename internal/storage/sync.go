@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"http-calendar/internal/models"
+	"sort"
+	"time"
+)
+
+// Tombstones are kept for tombstoneRetention, and at most maxTombstones per
+// user. Clients that synced before a pruned tombstone have to start over.
+var (
+	tombstoneRetention = 30 * 24 * time.Hour
+	maxTombstones      = 10000
+)
+
+type deletion struct {
+	eventID, seq uint64
+}
+
+func init() {
+	storage.epoch = time.Now().UnixNano()
+}
+
+// Epoch identifies the lifetime of the storage. Change sequences of an
+// earlier process or a cleared storage do not continue in it.
+func Epoch() int64 {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+	return storage.epoch
+}
+
+// SyncHorizon returns the latest change sequence of userID whose tombstone
+// was pruned. Changes since an earlier sequence can not be told completely.
+func SyncHorizon(userID uint64) uint64 {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+	return storage.horizon[userID]
+}
+
+// recordChange bumps the change sequence of userID and remembers at which
+// sequence eventID was last touched. Callers must hold storage.mu.
+func recordChange(userID, eventID uint64, deleted bool) {
+	if storage.seq == nil {
+		storage.seq = make(map[uint64]uint64)
+		storage.changed = make(map[uint64]map[uint64]uint64)
+		storage.tombstones = make(map[uint64]map[uint64]tombstone)
+	}
+	storage.seq[userID]++
+	seq := storage.seq[userID]
+
+	if deleted {
+		delete(storage.changed[userID], eventID)
+		if storage.tombstones[userID] == nil {
+			storage.tombstones[userID] = make(map[uint64]tombstone)
+		}
+		now := time.Now()
+		storage.tombstones[userID][eventID] = tombstone{seq: seq, deletedAt: now}
+		if storage.deletions == nil {
+			storage.deletions = make(map[uint64][]deletion)
+		}
+		storage.deletions[userID] = append(storage.deletions[userID], deletion{eventID: eventID, seq: seq})
+		pruneTombstones(userID, now)
+		return
+	}
+
+	delete(storage.tombstones[userID], eventID)
+	if storage.changed[userID] == nil {
+		storage.changed[userID] = make(map[uint64]uint64)
+	}
+	storage.changed[userID][eventID] = seq
+}
+
+// pruneTombstones drops the tombstones of userID that are older than the
+// retention or beyond the limit, oldest first. Callers must hold storage.mu.
+func pruneTombstones(userID uint64, now time.Time) {
+	deletions := storage.deletions[userID]
+	tombstones := storage.tombstones[userID]
+	for len(deletions) > 0 {
+		d := deletions[0]
+		t, ok := tombstones[d.eventID]
+		current := ok && t.seq == d.seq
+		// Entries of events deleted again or restored are dropped as they
+		// reach the front.
+		if current && len(tombstones) <= maxTombstones && now.Sub(t.deletedAt) <= tombstoneRetention {
+			break
+		}
+		if current {
+			delete(tombstones, d.eventID)
+			if storage.horizon == nil {
+				storage.horizon = make(map[uint64]uint64)
+			}
+			storage.horizon[userID] = d.seq
+		}
+		deletions = deletions[1:]
+	}
+	storage.deletions[userID] = deletions
+}
+
+// GetChangesSince returns the events of userID created or updated after the
+// change sequence since, the tombstones of events deleted after it, and the
+// current sequence to continue from. A zero since returns every event.
+func GetChangesSince(userID, since uint64) ([]models.Event, []models.Tombstone, uint64) {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	events := make([]models.Event, 0)
+	for id, event := range storage.m[userID] {
+		if since == 0 || storage.changed[userID][id] > since {
+			events = append(events, event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].EventID < events[j].EventID })
+
+	deleted := make([]models.Tombstone, 0)
+	if since > 0 {
+		for id, t := range storage.tombstones[userID] {
+			if t.seq > since {
+				deleted = append(deleted, models.Tombstone{EventID: id, DeletedAt: t.deletedAt})
+			}
+		}
+	}
+	sort.Slice(deleted, func(i, j int) bool { return deleted[i].EventID < deleted[j].EventID })
+
+	return events, deleted, storage.seq[userID]
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"http-calendar/internal/models"
+	"sort"
+)
+
+// indexTags moves the tag counts of the owner from the tags of previous to
+// the ones of event. Either may be nil. The caller must hold the lock.
+func indexTags(previous, event *models.Event) {
+	if previous != nil {
+		counts := storage.tags[previous.UserID]
+		for _, tag := range previous.Tags {
+			if counts[tag]--; counts[tag] <= 0 {
+				delete(counts, tag)
+			}
+		}
+	}
+	if event == nil || len(event.Tags) == 0 {
+		return
+	}
+	if storage.tags == nil {
+		storage.tags = make(map[uint64]map[string]int)
+	}
+	if storage.tags[event.UserID] == nil {
+		storage.tags[event.UserID] = make(map[string]int)
+	}
+	for _, tag := range event.Tags {
+		storage.tags[event.UserID][tag]++
+	}
+}
+
+// GetTagCounts returns the tags of the events of userID with their number
+// of events, the most used first. When counted is not nil, only the events
+// it accepts are counted.
+func GetTagCounts(userID uint64, counted func(models.Event) bool) []models.TagCount {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	counts := storage.tags[userID]
+	if counted != nil {
+		counts = make(map[string]int)
+		for _, event := range storage.m[userID] {
+			if counted(event) {
+				for _, tag := range event.Tags {
+					counts[tag]++
+				}
+			}
+		}
+	}
+	result := make([]models.TagCount, 0, len(counts))
+	for tag, count := range counts {
+		result = append(result, models.TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Tag < result[j].Tag
+	})
+	return result
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"http-calendar/internal/models"
+	"sort"
+	"time"
+)
+
+func CreateTask(task *models.Task) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if storage.tasks == nil {
+		storage.tasks = make(map[uint64]map[uint64]models.Task)
+	}
+	if storage.tasks[task.UserID] == nil {
+		storage.tasks[task.UserID] = make(map[uint64]models.Task)
+	}
+	if _, exists := storage.tasks[task.UserID][task.TaskID]; exists {
+		return models.ErrExistingEvent
+	}
+	if !calendarExists(task.UserID, task.CalendarID) {
+		return models.ErrCalendarNotFound
+	}
+	storage.tasks[task.UserID][task.TaskID] = *task
+	return nil
+}
+
+func UpdateTask(task *models.Task) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if _, ok := storage.tasks[task.UserID][task.TaskID]; !ok {
+		return models.ErrTaskNotFound
+	}
+	if !calendarExists(task.UserID, task.CalendarID) {
+		return models.ErrCalendarNotFound
+	}
+	storage.tasks[task.UserID][task.TaskID] = *task
+	return nil
+}
+
+func DeleteTask(userID, taskID uint64) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if _, ok := storage.tasks[userID][taskID]; !ok {
+		return models.ErrTaskNotFound
+	}
+	delete(storage.tasks[userID], taskID)
+	return nil
+}
+
+func GetTask(userID, taskID uint64) (models.Task, error) {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	task, ok := storage.tasks[userID][taskID]
+	if !ok {
+		return models.Task{}, models.ErrTaskNotFound
+	}
+	return task, nil
+}
+
+// GetTasks returns the tasks of userID ordered by due date, with tasks
+// without one last.
+func GetTasks(userID uint64) []models.Task {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	result := make([]models.Task, 0, len(storage.tasks[userID]))
+	for _, task := range storage.tasks[userID] {
+		result = append(result, task)
+	}
+	sortTasks(result)
+	return result
+}
+
+// GetTasksDue returns the tasks of userID due within [from, to) ordered by
+// due date.
+func GetTasksDue(userID uint64, from, to time.Time) []models.Task {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	result := make([]models.Task, 0)
+	for _, task := range storage.tasks[userID] {
+		if task.Due != nil && !task.Due.Before(from) && task.Due.Before(to) {
+			result = append(result, task)
+		}
+	}
+	sortTasks(result)
+	return result
+}
+
+func sortTasks(tasks []models.Task) {
+	sort.Slice(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if (a.Due == nil) != (b.Due == nil) {
+			return b.Due == nil
+		}
+		if a.Due != nil && !a.Due.Equal(*b.Due) {
+			return a.Due.Before(*b.Due)
+		}
+		return a.TaskID < b.TaskID
+	})
+}
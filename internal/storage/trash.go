@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"http-calendar/internal/models"
+	"http-calendar/internal/pubsub"
+	"sort"
+	"time"
+)
+
+// TrashEvent moves an event into the trash of its owner. To everyone else
+// it is deleted, but its attachments are kept until it is purged.
+func TrashEvent(userID, eventID uint64, at time.Time) (models.Event, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if storage.m == nil {
+		return models.Event{}, models.ErrUserNotFound
+	}
+	event, ok := storage.m[userID][eventID]
+	if !ok {
+		return models.Event{}, models.ErrEventNotFound
+	}
+	if storage.trash == nil {
+		storage.trash = make(map[uint64]map[uint64]models.TrashedEvent)
+	}
+	if storage.trash[userID] == nil {
+		storage.trash[userID] = make(map[uint64]models.TrashedEvent)
+	}
+	delete(storage.m[userID], eventID)
+	storage.trash[userID][eventID] = models.TrashedEvent{Event: event, DeletedAt: at}
+	indexEvent(&event, nil)
+	recordChange(userID, eventID, true)
+	pubsub.Publish(models.ChangeDeleted, event)
+	return event, nil
+}
+
+// GetTrashedEvent returns an event of userID from the trash.
+func GetTrashedEvent(userID, eventID uint64) (models.TrashedEvent, error) {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	trashed, ok := storage.trash[userID][eventID]
+	if !ok {
+		return models.TrashedEvent{}, models.ErrNotInTrash
+	}
+	return trashed, nil
+}
+
+// RestoreEvent moves an event out of the trash as event, which is checked
+// like a new one. Its attachments stayed with it in the trash.
+func RestoreEvent(event *models.Event, checks ...Check) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if _, ok := storage.trash[event.UserID][event.EventID]; !ok {
+		return models.ErrNotInTrash
+	}
+	if !calendarExists(event.UserID, event.CalendarID) {
+		return models.ErrCalendarNotFound
+	}
+	if err := checkBookings(event); err != nil {
+		return err
+	}
+	if err := runChecks(event, checks); err != nil {
+		return err
+	}
+
+	if storage.m == nil {
+		storage.m = make(map[uint64]map[uint64]models.Event)
+	}
+	if storage.m[event.UserID] == nil {
+		storage.m[event.UserID] = make(map[uint64]models.Event)
+	}
+	delete(storage.trash[event.UserID], event.EventID)
+	storage.m[event.UserID][event.EventID] = *event
+	indexEvent(nil, event)
+	recordChange(event.UserID, event.EventID, false)
+	pubsub.Publish(models.ChangeCreated, *event)
+	return nil
+}
+
+// GetTrash returns the trashed events of userID, the latest deleted first.
+func GetTrash(userID uint64) []models.TrashedEvent {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	result := make([]models.TrashedEvent, 0, len(storage.trash[userID]))
+	for _, trashed := range storage.trash[userID] {
+		result = append(result, trashed)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].DeletedAt.Equal(result[j].DeletedAt) {
+			return result[i].DeletedAt.After(result[j].DeletedAt)
+		}
+		return result[i].Event.EventID < result[j].Event.EventID
+	})
+	return result
+}
+
+// PurgeTrash removes the events trashed before the given time for good and
+// returns them.
+func PurgeTrash(before time.Time) []models.Event {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	var purged []models.Event
+	for _, trash := range storage.trash {
+		for id, trashed := range trash {
+			if !trashed.DeletedAt.Before(before) {
+				continue
+			}
+			delete(trash, id)
+			indexAttachments(&trashed.Event, nil)
+			purged = append(purged, trashed.Event)
+		}
+	}
+	return purged
+}
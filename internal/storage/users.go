@@ -0,0 +1,35 @@
+package storage
+
+import "http-calendar/internal/models"
+
+func SetUser(user *models.User) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if storage.users == nil {
+		storage.users = make(map[uint64]models.User)
+	}
+	storage.users[user.UserID] = *user
+}
+
+func GetUser(userID uint64) (models.User, error) {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	user, ok := storage.users[userID]
+	if !ok {
+		return models.User{}, models.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func GetUsers() []models.User {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	result := make([]models.User, 0, len(storage.users))
+	for _, user := range storage.users {
+		result = append(result, user)
+	}
+	return result
+}
@@ -0,0 +1,34 @@
+// Package trash runs the background removal of deleted events whose trash
+// retention has ended.
+package trash
+
+import (
+	"context"
+	"http-calendar/internal/service"
+	"time"
+)
+
+const checkInterval = time.Hour
+
+// Purger purges expired events from the trash of every user.
+type Purger struct {
+	now func() time.Time
+}
+
+func NewPurger() *Purger {
+	return &Purger{now: time.Now}
+}
+
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		service.PurgeTrash(p.now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
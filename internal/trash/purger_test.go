@@ -0,0 +1,37 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"http-calendar/internal/models"
+	"http-calendar/internal/service"
+	"http-calendar/internal/storage"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPurgerRun(t *testing.T) {
+	storage.Clear()
+	service.SetTrashRetention(time.Hour)
+	t.Cleanup(func() { service.SetTrashRetention(0) })
+
+	event, err := service.CreateEvent("1", "2024-01-15", "Standup", "")
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	id := strconv.FormatUint(event.EventID, 10)
+	if err = service.DeleteEvent("1", id); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p := NewPurger()
+	p.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	p.Run(ctx)
+
+	if _, err = service.RestoreEvent("1", id); !errors.Is(err, models.ErrNotInTrash) {
+		t.Errorf("RestoreEvent() after purge error = %v, want %v", err, models.ErrNotInTrash)
+	}
+}
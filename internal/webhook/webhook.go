@@ -0,0 +1,536 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"http-calendar/internal/models"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Calendar-Signature"
+	TimestampHeader = "X-Calendar-Timestamp"
+	DeliveryHeader  = "X-Calendar-Delivery"
+	EventHeader     = "X-Calendar-Event"
+
+	historySize = 100
+)
+
+var (
+	maxAttempts    = 8
+	maxDeadLetters = 100
+	baseBackoff    = time.Second
+	maxBackoff     = time.Hour
+	pollInterval   = 500 * time.Millisecond
+	client         = newClient()
+	allowPrivate   atomic.Bool
+)
+
+var state struct {
+	mu        sync.Mutex
+	path      string
+	nextID    uint64
+	hooks     map[uint64]map[uint64]models.Webhook
+	queue     []*models.Delivery
+	delivered map[uint64][]*models.Delivery
+	dead      []*models.Delivery
+	wake      chan struct{}
+}
+
+type snapshot struct {
+	NextID uint64             `json:"next_id"`
+	Hooks  []models.Webhook   `json:"hooks"`
+	Queue  []*models.Delivery `json:"queue"`
+	Dead   []*models.Delivery `json:"dead"`
+}
+
+type payload struct {
+	DeliveryID uint64       `json:"delivery_id"`
+	Type       string       `json:"type"`
+	UserID     uint64       `json:"user_id"`
+	Event      models.Event `json:"event"`
+	Time       time.Time    `json:"time"`
+}
+
+// Init restores webhooks and the pending delivery queue from path. An empty
+// path keeps everything in memory.
+func Init(path string) error {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	reset()
+	state.path = path
+	if path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snap snapshot
+	if err = json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+	state.nextID = snap.NextID
+	for _, hook := range snap.Hooks {
+		if state.hooks[hook.UserID] == nil {
+			state.hooks[hook.UserID] = make(map[uint64]models.Webhook)
+		}
+		state.hooks[hook.UserID][hook.WebhookID] = hook
+	}
+	state.queue = snap.Queue
+	state.dead = snap.Dead
+	return nil
+}
+
+// AllowPrivateTargets permits webhooks to loopback, link-local and private
+// addresses. They are refused by default so users can not make the server
+// post to internal services.
+func AllowPrivateTargets(allow bool) {
+	allowPrivate.Store(allow)
+}
+
+func Create(userID uint64, rawURL, secret string) (*models.Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return nil, models.ErrInvalidURL
+	}
+	if err = checkHost(u.Hostname()); err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err = rand.Read(buf); err != nil {
+			return nil, err
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if state.hooks == nil {
+		reset()
+	}
+	state.nextID++
+	hook := models.Webhook{
+		WebhookID: state.nextID,
+		UserID:    userID,
+		URL:       u.String(),
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}
+	if state.hooks[userID] == nil {
+		state.hooks[userID] = make(map[uint64]models.Webhook)
+	}
+	state.hooks[userID][hook.WebhookID] = hook
+	save()
+	return &hook, nil
+}
+
+func Delete(userID, webhookID uint64) error {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if _, ok := state.hooks[userID][webhookID]; !ok {
+		return models.ErrWebhookNotFound
+	}
+	delete(state.hooks[userID], webhookID)
+
+	queue := state.queue[:0]
+	for _, d := range state.queue {
+		if d.WebhookID != webhookID {
+			queue = append(queue, d)
+		}
+	}
+	state.queue = queue
+
+	dead := state.dead[:0]
+	for _, d := range state.dead {
+		if d.WebhookID != webhookID {
+			dead = append(dead, d)
+		}
+	}
+	state.dead = dead
+	save()
+	return nil
+}
+
+// List returns the webhooks of userID without their secrets.
+func List(userID uint64) []models.Webhook {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	result := make([]models.Webhook, 0, len(state.hooks[userID]))
+	for _, hook := range state.hooks[userID] {
+		hook.Secret = ""
+		result = append(result, hook)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].WebhookID < result[j].WebhookID })
+	return result
+}
+
+// Notify queues a delivery of the change to every webhook of the event owner.
+func Notify(changeType string, event models.Event) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if len(state.hooks[event.UserID]) == 0 {
+		return
+	}
+	now := time.Now()
+	for _, hook := range state.hooks[event.UserID] {
+		state.nextID++
+		state.queue = append(state.queue, &models.Delivery{
+			DeliveryID:  state.nextID,
+			WebhookID:   hook.WebhookID,
+			UserID:      hook.UserID,
+			URL:         hook.URL,
+			Change:      models.Change{Type: changeType, UserID: event.UserID, Event: event, Time: now},
+			Status:      models.DeliveryPending,
+			NextAttempt: now,
+		})
+	}
+	save()
+
+	select {
+	case state.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Deliveries lists the pending and recently completed deliveries of userID
+// together with their attempts, newest first.
+func Deliveries(userID uint64) []models.Delivery {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	var result []models.Delivery
+	for _, d := range state.queue {
+		if d.UserID == userID {
+			result = append(result, copyDelivery(d))
+		}
+	}
+	for _, d := range state.delivered[userID] {
+		result = append(result, copyDelivery(d))
+	}
+	for _, d := range state.dead {
+		if d.UserID == userID {
+			result = append(result, copyDelivery(d))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].DeliveryID > result[j].DeliveryID })
+	return result
+}
+
+func DeadLetters(userID uint64) []models.Delivery {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	result := make([]models.Delivery, 0)
+	for _, d := range state.dead {
+		if d.UserID == userID {
+			result = append(result, copyDelivery(d))
+		}
+	}
+	return result
+}
+
+// Run delivers queued webhooks until ctx is cancelled.
+func Run(ctx context.Context) {
+	state.mu.Lock()
+	if state.wake == nil {
+		state.wake = make(chan struct{}, 1)
+	}
+	wake := state.wake
+	state.mu.Unlock()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		deliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+func deliverDue(ctx context.Context) {
+	now := time.Now()
+
+	state.mu.Lock()
+	var due []models.Delivery
+	for _, d := range state.queue {
+		if !d.NextAttempt.After(now) {
+			// Lease the delivery so a slow receiver does not get it twice.
+			d.NextAttempt = now.Add(client.Timeout)
+			due = append(due, copyDelivery(d))
+		}
+	}
+	state.mu.Unlock()
+
+	for _, d := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		attempt := send(ctx, d)
+		if ctx.Err() != nil {
+			// Interrupted by shutdown, the lease expires and the delivery is retried.
+			return
+		}
+		complete(d.DeliveryID, attempt)
+	}
+}
+
+func send(ctx context.Context, d models.Delivery) models.DeliveryAttempt {
+	attempt := models.DeliveryAttempt{Time: time.Now()}
+
+	state.mu.Lock()
+	hook, ok := state.hooks[d.UserID][d.WebhookID]
+	state.mu.Unlock()
+	if !ok {
+		attempt.Error = models.ErrWebhookNotFound.Error()
+		return attempt
+	}
+
+	body, err := json.Marshal(payload{
+		DeliveryID: d.DeliveryID,
+		Type:       d.Change.Type,
+		UserID:     d.UserID,
+		Event:      d.Change.Event,
+		Time:       d.Change.Time,
+	})
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := strconv.FormatInt(attempt.Time.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(d.DeliveryID, 10))
+	req.Header.Set(EventHeader, d.Change.Type)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(hook.Secret, timestamp, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer func() {
+		if err = resp.Body.Close(); err != nil {
+			log.Printf("Error closing webhook response: %v\n", err)
+		}
+	}()
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("unexpected status %s", resp.Status)
+	}
+	return attempt
+}
+
+func complete(deliveryID uint64, attempt models.DeliveryAttempt) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	i := -1
+	for j, d := range state.queue {
+		if d.DeliveryID == deliveryID {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return
+	}
+	d := state.queue[i]
+	d.Attempts = append(d.Attempts, attempt)
+
+	switch {
+	case attempt.Error == "":
+		d.Status = models.DeliveryDelivered
+		state.queue = append(state.queue[:i], state.queue[i+1:]...)
+		history := append(state.delivered[d.UserID], d)
+		if len(history) > historySize {
+			history = history[len(history)-historySize:]
+		}
+		state.delivered[d.UserID] = history
+	case len(d.Attempts) >= maxAttempts:
+		d.Status = models.DeliveryDead
+		state.queue = append(state.queue[:i], state.queue[i+1:]...)
+		state.dead = append(state.dead, d)
+		trimDeadLetters(d.WebhookID)
+		log.Printf("webhook delivery %d to %s moved to dead letters: %s\n", d.DeliveryID, d.URL, attempt.Error)
+	default:
+		d.NextAttempt = attempt.Time.Add(backoff(len(d.Attempts)))
+	}
+	save()
+}
+
+// trimDeadLetters drops the oldest dead letters of webhookID beyond
+// maxDeadLetters.
+func trimDeadLetters(webhookID uint64) {
+	count := 0
+	for _, d := range state.dead {
+		if d.WebhookID == webhookID {
+			count++
+		}
+	}
+
+	dead := state.dead[:0]
+	for _, d := range state.dead {
+		if d.WebhookID == webhookID && count > maxDeadLetters {
+			count--
+			continue
+		}
+		dead = append(dead, d)
+	}
+	state.dead = dead
+}
+
+func backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of "timestamp.body" that
+// receivers use to verify the X-Calendar-Signature header.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newClient returns a client that checks every address it connects to, so a
+// host that resolves to an internal address after the webhook was created is
+// still refused.
+func newClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: checkDial}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: 10 * time.Second, Transport: transport}
+}
+
+func checkDial(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !permitted(ip) {
+		return models.ErrPrivateURL
+	}
+	return nil
+}
+
+// checkHost refuses hosts that are or resolve to a non-public address.
+func checkHost(host string) error {
+	if allowPrivate.Load() {
+		return nil
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return models.ErrInvalidURL
+	}
+	for _, ip := range ips {
+		if !permitted(ip) {
+			return models.ErrPrivateURL
+		}
+	}
+	return nil
+}
+
+func permitted(ip net.IP) bool {
+	if allowPrivate.Load() {
+		return true
+	}
+	return ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
+
+func copyDelivery(d *models.Delivery) models.Delivery {
+	c := *d
+	c.Attempts = append([]models.DeliveryAttempt(nil), d.Attempts...)
+	return c
+}
+
+func save() {
+	if state.path == "" {
+		return
+	}
+
+	snap := snapshot{NextID: state.nextID, Queue: state.queue, Dead: state.dead}
+	for _, hooks := range state.hooks {
+		for _, hook := range hooks {
+			snap.Hooks = append(snap.Hooks, hook)
+		}
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		log.Printf("Error encoding webhook store: %v\n", err)
+		return
+	}
+
+	tmp := state.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0600); err != nil {
+		log.Printf("Error writing webhook store: %v\n", err)
+		return
+	}
+	if err = os.Rename(tmp, state.path); err != nil {
+		log.Printf("Error writing webhook store: %v\n", err)
+	}
+}
+
+func reset() {
+	state.nextID = 0
+	state.hooks = make(map[uint64]map[uint64]models.Webhook)
+	state.queue = nil
+	state.delivered = make(map[uint64][]*models.Delivery)
+	state.dead = nil
+	if state.wake == nil {
+		state.wake = make(chan struct{}, 1)
+	}
+}
@@ -0,0 +1,262 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"http-calendar/internal/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func setup(t *testing.T) {
+	t.Helper()
+	if err := Init(""); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	baseBackoff = time.Millisecond
+	maxBackoff = 10 * time.Millisecond
+	pollInterval = time.Millisecond
+	// Test servers listen on loopback.
+	AllowPrivateTargets(true)
+}
+
+func runWorker(t *testing.T) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDeliverySigned(t *testing.T) {
+	setup(t)
+
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	hook, err := Create(1, server.URL, "secret")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	runWorker(t)
+
+	Notify(models.ChangeCreated, models.Event{UserID: 1, EventID: 7, Title: "Meeting"})
+
+	r := <-received
+	body := <-bodies
+	want := "sha256=" + Sign(hook.Secret, r.Header.Get(TimestampHeader), body)
+	if got := r.Header.Get(SignatureHeader); got != want {
+		t.Errorf("Signature = %v, want %v", got, want)
+	}
+	if got := r.Header.Get(EventHeader); got != models.ChangeCreated {
+		t.Errorf("Event header = %v, want %v", got, models.ChangeCreated)
+	}
+
+	var p payload
+	if err = json.Unmarshal(body, &p); err != nil {
+		t.Fatalf("Invalid payload: %v", err)
+	}
+	if p.Event.EventID != 7 || p.Type != models.ChangeCreated {
+		t.Errorf("Unexpected payload %+v", p)
+	}
+
+	waitFor(t, func() bool {
+		d := Deliveries(1)
+		return len(d) == 1 && d[0].Status == models.DeliveryDelivered
+	})
+}
+
+func TestDeliveryRetried(t *testing.T) {
+	setup(t)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	if _, err := Create(1, server.URL, ""); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	runWorker(t)
+
+	Notify(models.ChangeUpdated, models.Event{UserID: 1, EventID: 1})
+
+	waitFor(t, func() bool {
+		d := Deliveries(1)
+		return len(d) == 1 && d[0].Status == models.DeliveryDelivered
+	})
+	d := Deliveries(1)[0]
+	if len(d.Attempts) != 3 {
+		t.Errorf("Expected 3 attempts, got %d", len(d.Attempts))
+	}
+	if d.Attempts[0].StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected failed first attempt, got %+v", d.Attempts[0])
+	}
+}
+
+func TestDeliveryDeadLetter(t *testing.T) {
+	setup(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	if _, err := Create(1, server.URL, ""); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	runWorker(t)
+
+	Notify(models.ChangeDeleted, models.Event{UserID: 1, EventID: 1})
+
+	waitFor(t, func() bool { return len(DeadLetters(1)) == 1 })
+	if got := len(DeadLetters(1)[0].Attempts); got != maxAttempts {
+		t.Errorf("Expected %d attempts, got %d", maxAttempts, got)
+	}
+}
+
+func TestDeadLettersCapped(t *testing.T) {
+	setup(t)
+	defer func(attempts, dead int) { maxAttempts, maxDeadLetters = attempts, dead }(maxAttempts, maxDeadLetters)
+	maxAttempts, maxDeadLetters = 1, 2
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	if _, err := Create(1, server.URL, ""); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	for i := uint64(1); i <= 4; i++ {
+		Notify(models.ChangeUpdated, models.Event{UserID: 1, EventID: i})
+	}
+	deliverDue(context.Background())
+
+	dead := DeadLetters(1)
+	if len(dead) != 2 {
+		t.Fatalf("Expected 2 dead letters, got %d", len(dead))
+	}
+	if dead[0].Change.Event.EventID != 3 || dead[1].Change.Event.EventID != 4 {
+		t.Errorf("Expected the newest dead letters, got events %d and %d", dead[0].Change.Event.EventID, dead[1].Change.Event.EventID)
+	}
+}
+
+func TestPrivateTargetsRefused(t *testing.T) {
+	setup(t)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	if _, err := Create(1, server.URL, ""); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	AllowPrivateTargets(false)
+	defer AllowPrivateTargets(true)
+
+	for _, u := range []string{server.URL, "http://localhost/hook", "http://10.0.0.1/hook", "http://169.254.169.254/latest", "http://[::1]/hook"} {
+		if _, err := Create(1, u, ""); !errors.Is(err, models.ErrPrivateURL) {
+			t.Errorf("Create(%q) error = %v, want %v", u, err, models.ErrPrivateURL)
+		}
+	}
+
+	// A webhook that was accepted earlier is still checked on delivery.
+	Notify(models.ChangeCreated, models.Event{UserID: 1, EventID: 1})
+	deliverDue(context.Background())
+
+	if calls.Load() != 0 {
+		t.Errorf("Expected no request to the private address, got %d", calls.Load())
+	}
+	d := Deliveries(1)
+	if len(d) != 1 || len(d[0].Attempts) != 1 || !strings.Contains(d[0].Attempts[0].Error, models.ErrPrivateURL.Error()) {
+		t.Errorf("Expected a refused attempt, got %+v", d)
+	}
+}
+
+func TestQueuePersisted(t *testing.T) {
+	setup(t)
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+	if err := Init(path); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	if _, err := Create(1, server.URL, ""); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	Notify(models.ChangeCreated, models.Event{UserID: 1, EventID: 1})
+
+	// Simulate a restart before the worker had a chance to deliver.
+	if err := Init(path); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if got := len(List(1)); got != 1 {
+		t.Fatalf("Expected restored webhook, got %d", got)
+	}
+	runWorker(t)
+
+	waitFor(t, func() bool { return calls.Load() == 1 })
+}
+
+func TestCreate_InvalidURL(t *testing.T) {
+	setup(t)
+
+	for _, u := range []string{"", "ftp://example.com", "not a url", "http://"} {
+		if _, err := Create(1, u, ""); err == nil {
+			t.Errorf("Create(%q) expected error", u)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	baseBackoff = time.Second
+	maxBackoff = 5 * time.Second
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}